@@ -1,26 +1,23 @@
 package algorithm
 
+// Request is a student's answer to the request form. Choices holds the ranked course choices of every
+// time slot, first choice first, as many as the form asked for. A course that takes up several time
+// slots is chosen among the choices of its first one.
 type Request struct {
-	Email     string `csv:"Email Address"`
-	FirstName string `csv:"Students First Name"`
-	LastName  string `csv:"Students Last Name"`
-	Grade     string `csv:"Grade in school this year"`
-	AMFD1     string `csv:"AM Course - 1st Choice. (Drop down option)"`
-	AMFD2     string `csv:"AM Course - 2nd Choice. (Drop down option)"`
-	AMFD3     string `csv:"AM Course - 3rd Choice. (Drop down option)"`
-	AMFD4     string `csv:"AM Course - 4th Choice. (Drop down option)"`
-	AMFD5     string `csv:"AM Course - 5th Choice. (Drop down option)"`
-	PM1       string `csv:"PM Course - 1st Choice. (Drop down option)"`
-	PM2       string `csv:"PM Course - 2nd Choice. (Drop down option)"`
-	PM3       string `csv:"PM Course - 3rd Choice. (Drop down option)"`
-	PM4       string `csv:"PM Course - 4th Choice. (Drop down option)"`
-	PM5       string `csv:"PM Course - 5th Choice. (Drop down option)"`
+	Email     string
+	FirstName string
+	LastName  string
+	Grade     string
+	Choices   map[string][]string
+	// Timestamp is when the form was submitted, empty when the requests have no timestamp column
+	Timestamp string
+	// Line is the line of the requests file the request was read from
+	Line int `json:"-"`
+	// Attributes holds the answers of the columns that are not mapped to a field, by their header
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
-func (r *Request) GetAMCourses() []string {
-	return []string{r.AMFD1, r.AMFD2, r.AMFD3, r.AMFD4, r.AMFD5}
-}
-
-func (r *Request) GetPMCourses() []string {
-	return []string{r.PM1, r.PM2, r.PM3, r.PM4, r.PM5}
+// GetCourses returns the ranked course choices of a time slot
+func (r *Request) GetCourses(timeSlot string) []string {
+	return r.Choices[timeSlot]
 }
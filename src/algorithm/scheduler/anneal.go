@@ -0,0 +1,383 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// AnnealOptions configures the simulated annealing pass. The temperature cools geometrically
+// from StartTemperature to EndTemperature over the given number of steps, and the pass stops
+// early once the time budget runs out. A zero budget means no time limit.
+type AnnealOptions struct {
+	StartTemperature float64       `json:"startTemperature"`
+	EndTemperature   float64       `json:"endTemperature"`
+	Steps            int           `json:"steps"`
+	Budget           time.Duration `json:"budget"`
+
+	// stopAfter replays a pass that was cut short by its budget
+	stopAfter int
+}
+
+var DefaultAnnealOptions = AnnealOptions{
+	StartTemperature: 4,
+	EndTemperature:   0.05,
+	Steps:            1000000,
+	Budget:           5 * time.Second,
+}
+
+// Validate rejects temperatures the acceptance probability can't be computed with
+func (o AnnealOptions) Validate() error {
+	if o.StartTemperature <= 0 || o.EndTemperature <= 0 {
+		return fmt.Errorf("annealing temperatures must be positive, got a start temperature of %g and an end temperature of %g", o.StartTemperature, o.EndTemperature)
+	}
+	return nil
+}
+
+// AnnealStrategy runs the greedy strategy and then improves its best schedule with simulated annealing
+type AnnealStrategy struct{}
+
+func init() {
+	RegisterStrategy(AnnealStrategy{})
+}
+
+func (AnnealStrategy) Name() string {
+	return "anneal"
+}
+
+func (AnnealStrategy) Schedule(s *Scheduler, numIterations int) *Schedule {
+	schedule := GreedyStrategy{}.Schedule(s, numIterations)
+	if schedule == nil {
+		return nil
+	}
+	options := s.Anneal
+	if options == nil {
+		options = &DefaultAnnealOptions
+	}
+	// The annealing pass gets the random source of the trial after the last one
+	s.rng = rand.New(rand.NewSource(s.Seed + int64(numIterations)))
+	return s.Improve(schedule, *options)
+}
+
+// Improve runs a local search over the schedule that moves single students to another section of the
+// same time slots, or swaps two students between such sections when the target is full, as long as
+// their grades may take the sections, and seats students in the open sections of the time slots they
+// have no course in. A student moved into a section taking up other time slots than the one they leave,
+// such as a full day course for a morning course, leaves every section overlapping it and is seated in
+// open sections of the slots that frees. Worse moves are accepted with the usual annealing probability.
+// The given schedule is left untouched.
+func (s *Scheduler) Improve(schedule *Schedule, options AnnealOptions) *Schedule {
+	students := make([]*imp.Student, len(schedule.Students))
+	for i, student := range schedule.Students {
+		students[i] = student.DeepCopy()
+	}
+	sections := make(map[sectionKey]*imp.Section, len(s.sortedSections()))
+	sectionsByCourse := make(map[string][]*imp.Section)
+	// sectionsOccupying lists the sections that take up every time slot
+	sectionsOccupying := make(map[string][]*imp.Section)
+	ordered := make([]*imp.Section, 0, len(s.sortedSections()))
+	for _, section := range s.sortedSections() {
+		copied := section.EmptyCopy()
+		ordered = append(ordered, copied)
+		sections[keyOf(section.Course)] = copied
+		sectionsByCourse[section.Course.CourseName] = append(sectionsByCourse[section.Course.CourseName], copied)
+		for _, timeSlot := range section.Course.Slots {
+			sectionsOccupying[timeSlot] = append(sectionsOccupying[timeSlot], copied)
+		}
+	}
+	enrolledSections := func(student *imp.Student) []*imp.Section {
+		var enrolled []*imp.Section
+		for _, course := range student.EnrolledCourses.Courses() {
+			if section, ok := sections[keyOf(&course)]; ok {
+				enrolled = append(enrolled, section)
+			}
+		}
+		return enrolled
+	}
+	for _, student := range students {
+		for _, section := range enrolledSections(student) {
+			section.AddStudent(student)
+		}
+	}
+	byEmail := s.studentsByEmail(students)
+	balanced := len(s.balanceRules()) > 0
+
+	current := s.schedulePenalty(students) + s.balancePenalty(ordered...)
+	for _, student := range students {
+		current += student.SatisfactionScore()
+	}
+	best := current
+	bestEnrollments := make([]imp.EnrolledCourses, len(students))
+	saveBest := func() {
+		for i, student := range students {
+			bestEnrollments[i] = student.CopyEnrolledCourses()
+		}
+	}
+	saveBest()
+
+	rng := s.random()
+	// seat puts a student without a course in the time slot into a random open section of it they may join.
+	// Leaving a slot unseated outweighs any course, so the move is always taken.
+	seat := func(student *imp.Student, timeSlot string) bool {
+		candidates := sectionsOccupying[timeSlot]
+		if len(candidates) == 0 {
+			return false
+		}
+		to := candidates[rng.Intn(len(candidates))]
+		if len(to.Students) >= to.MaxStudents || !to.Course.IsEligible(student.Grade) || !student.Free(to.Course) || !s.balanceAllows(student, to, nil) {
+			return false
+		}
+		if _, found := s.mateIn(student, sectionsByCourse[to.Course.CourseName], nil); found {
+			return false
+		}
+		affected := s.groupsAffected(to.Course, student)
+		delta := -student.SatisfactionScore() - s.groupPenalty(affected, byEmail) - s.balancePenalty(to)
+		to.AddStudent(student)
+		student.AddEnrolledCourse(to.Course)
+		delta += student.SatisfactionScore() + s.groupPenalty(affected, byEmail) + s.balancePenalty(to)
+		current += delta
+		if current < best-1e-9 {
+			best = current
+			saveBest()
+		}
+		return true
+	}
+	// reseat moves a student into a section taking up other time slots than the one they leave. The student
+	// leaves every section overlapping it and is seated in random open sections of the slots that frees,
+	// the slots that can't be filled are left unseated. The move is kept with the annealing probability.
+	reseat := func(student *imp.Student, to *imp.Section, temperature float64) {
+		if len(to.Students) >= to.MaxStudents || !s.balanceAllows(student, to, nil) {
+			return
+		}
+		if _, found := s.mateIn(student, sectionsByCourse[to.Course.CourseName], nil); found {
+			return
+		}
+		var leaving []*imp.Section
+		var freedSlots []string
+		freed := make(map[string]bool)
+		for _, section := range enrolledSections(student) {
+			if !overlaps(section, to) {
+				continue
+			}
+			if s.isLocked(student, section) {
+				return
+			}
+			leaving = append(leaving, section)
+			for _, slot := range section.Course.Slots {
+				if !to.Course.Occupies(slot) {
+					freedSlots = append(freedSlots, slot)
+					freed[slot] = true
+				}
+			}
+		}
+		joining := []*imp.Section{to}
+		for _, slot := range freedSlots {
+			candidates := sectionsOccupying[slot]
+			if !freed[slot] || len(candidates) == 0 {
+				continue
+			}
+			fill := candidates[rng.Intn(len(candidates))]
+			fits := len(fill.Students) < fill.MaxStudents && fill.Course.IsEligible(student.Grade) && s.balanceAllows(student, fill, nil)
+			for _, slot := range fill.Course.Slots {
+				fits = fits && freed[slot]
+			}
+			if _, found := s.mateIn(student, sectionsByCourse[fill.Course.CourseName], nil); !fits || found {
+				continue
+			}
+			for _, slot := range fill.Course.Slots {
+				freed[slot] = false
+			}
+			joining = append(joining, fill)
+		}
+
+		touched := append(append([]*imp.Section(nil), leaving...), joining...)
+		var affected []int
+		for _, section := range touched {
+			affected = append(affected, s.groupsAffected(section.Course, student)...)
+		}
+		affected = uniqueGroups(affected)
+		cost := func() float64 {
+			return student.SatisfactionScore() + s.groupPenalty(affected, byEmail) + s.balancePenalty(touched...)
+		}
+		move := func(from, to []*imp.Section) {
+			for _, section := range from {
+				section.RemoveStudent(student)
+				student.RemoveEnrolledCourse(section.Course)
+			}
+			for _, section := range to {
+				section.AddStudent(student)
+				student.AddEnrolledCourse(section.Course)
+			}
+		}
+		delta := -cost()
+		move(leaving, joining)
+		delta += cost()
+		if delta > 0 && rng.Float64() >= math.Exp(-delta/temperature) {
+			move(joining, leaving)
+			return
+		}
+		current += delta
+		if current < best-1e-9 {
+			best = current
+			saveBest()
+		}
+	}
+	start := time.Now()
+	temperature := options.StartTemperature
+	steps := 0
+	for ; steps < options.Steps && len(students) > 0; steps++ {
+		if options.stopAfter > 0 && steps >= options.stopAfter {
+			break
+		}
+		if steps%1000 == 0 {
+			if s.stopped() || (options.Budget > 0 && options.stopAfter == 0 && time.Since(start) >= options.Budget) {
+				break
+			}
+			s.reportScore(best)
+			progress := float64(steps) / float64(options.Steps)
+			temperature = options.StartTemperature * math.Pow(options.EndTemperature/options.StartTemperature, progress)
+		}
+
+		student := students[rng.Intn(len(students))]
+		if open := student.UnseatedSlots(); len(open) > 0 && seat(student, open[rng.Intn(len(open))]) {
+			continue
+		}
+		enrolled := enrolledSections(student)
+		if len(enrolled) == 0 {
+			continue
+		}
+		from := enrolled[rng.Intn(len(enrolled))]
+		candidates := sectionsOccupying[from.Course.Slots[rng.Intn(len(from.Course.Slots))]]
+		to := candidates[rng.Intn(len(candidates))]
+		if to == from || !to.Course.IsEligible(student.Grade) || s.isLocked(student, from) {
+			continue
+		}
+		if !sameSlots(from.Course, to.Course) {
+			reseat(student, to, temperature)
+			continue
+		}
+		// Students who must be kept apart never share a course, whichever section
+		if _, found := s.mateIn(student, sectionsByCourse[to.Course.CourseName], nil); found && to.Course.CourseName != from.Course.CourseName {
+			continue
+		}
+
+		var other *imp.Student
+		delta := student.CourseCost(to.Course) - student.CourseCost(from.Course)
+		if len(to.Students) >= to.MaxStudents {
+			if len(to.Students) == 0 {
+				continue
+			}
+			other = to.Students[rng.Intn(len(to.Students))]
+			if !from.Course.IsEligible(other.Grade) || s.isLocked(other, to) || !s.balanceAllows(other, from, student) {
+				continue
+			}
+			if _, found := s.mateIn(other, sectionsByCourse[from.Course.CourseName], student); found && to.Course.CourseName != from.Course.CourseName {
+				continue
+			}
+			delta += other.CourseCost(from.Course) - other.CourseCost(to.Course)
+		}
+		if !s.balanceAllows(student, to, other) {
+			continue
+		}
+		// Group requests and balance rules depend on where the others are, so a move that affects any is
+		// made to see what it changes
+		affected := s.groupsAffected(from.Course, student, other)
+		moved := len(affected) > 0 || balanced
+		if moved {
+			delta -= s.groupPenalty(affected, byEmail) + s.balancePenalty(from, to)
+			s.moveStudent(student, from, to)
+			if other != nil {
+				s.moveStudent(other, to, from)
+			}
+			delta += s.groupPenalty(affected, byEmail) + s.balancePenalty(from, to)
+		}
+		if delta > 0 && rng.Float64() >= math.Exp(-delta/temperature) {
+			if moved {
+				if other != nil {
+					s.moveStudent(other, from, to)
+				}
+				s.moveStudent(student, to, from)
+			}
+			continue
+		}
+
+		if !moved {
+			s.moveStudent(student, from, to)
+			if other != nil {
+				s.moveStudent(other, to, from)
+			}
+		}
+		current += delta
+		if current < best-1e-9 {
+			best = current
+			saveBest()
+		}
+	}
+
+	improved := &Schedule{
+		Students:    students,
+		Trial:       schedule.Trial,
+		GreedyScore: schedule.Score,
+		Improved:    true,
+		AnnealSteps: steps,
+	}
+	for _, section := range sections {
+		section.ClearStudents()
+	}
+	// Moves between sections of the same course don't change the score of students without a group when
+	// there are no balance rules, so the best schedule spreads them over the sections of every course again
+	// around the other seats
+	for i, student := range students {
+		student.EnrolledCourses = bestEnrollments[i]
+		for _, section := range enrolledSections(student) {
+			if s.isLocked(student, section) || s.hasGroup(student) || balanced {
+				section.AddStudent(student)
+			}
+		}
+	}
+	for _, student := range students {
+		for _, section := range enrolledSections(student) {
+			if s.isLocked(student, section) || s.hasGroup(student) || balanced {
+				continue
+			}
+			section = leastFilledSection(sectionsByCourse[section.Course.CourseName])
+			section.AddStudent(student)
+			student.AddEnrolledCourse(section.Course)
+		}
+		improved.Score += student.SatisfactionScore()
+	}
+	improved.Score += s.schedulePenalty(students) + s.balancePenalty(ordered...)
+	for _, section := range s.sortedSections() {
+		improved.Sections = append(improved.Sections, sections[keyOf(section.Course)].DeepCopy())
+	}
+	return improved
+}
+
+// sameSlots reports whether the two courses take up the same time slots
+func sameSlots(a, b *imp.Course) bool {
+	if len(a.Slots) != len(b.Slots) {
+		return false
+	}
+	for i := range a.Slots {
+		if a.Slots[i] != b.Slots[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueGroups drops the repeated group indexes, keeping the first of each
+func uniqueGroups(groups []int) []int {
+	seen := make(map[int]bool, len(groups))
+	unique := groups[:0]
+	for _, i := range groups {
+		if !seen[i] {
+			seen[i] = true
+			unique = append(unique, i)
+		}
+	}
+	return unique
+}
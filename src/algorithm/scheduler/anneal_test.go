@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+func TestAnnealSeatsUnseatedStudents(t *testing.T) {
+	s := newTestScheduler(t, crowdedRequests, crowdedEvents)
+	s.Anneal = &AnnealOptions{StartTemperature: 4, EndTemperature: 0.05, Steps: 20000}
+	schedule := AnnealStrategy{}.Schedule(s, 10)
+	if schedule.GreedyScore < 1600 {
+		t.Fatalf("greedy scored %g, want the freshman left unseated", schedule.GreedyScore)
+	}
+	if unseated(schedule) != 0 || schedule.Score != 1 {
+		t.Errorf("annealing scored %g with %d unseated slots, want 1 with everyone seated", schedule.Score, unseated(schedule))
+	}
+}
+
+// The junior, scheduled first, takes the full day course, which is the only course the freshman may take.
+// The freshman is only seated when the junior trades it for a course in each half of the day.
+const fullDayRequests = choiceHeaders +
+	"j@x.org,J,J,Junior,F,X,F,P\n" +
+	"f@x.org,F,F,Freshman,F,,F,\n"
+
+const fullDayEvents = "Name,Max Students,Time Slot,Grades\n" +
+	"F,1,FullDay,\n" +
+	"X,1,AM,Junior\n" +
+	"P,1,PM,Junior\n"
+
+func TestAnnealTradesAFullDayCourseForHalfDayCourses(t *testing.T) {
+	s := newTestScheduler(t, fullDayRequests, fullDayEvents)
+	s.Anneal = &AnnealOptions{StartTemperature: 4, EndTemperature: 0.05, Steps: 20000}
+	schedule := AnnealStrategy{}.Schedule(s, 10)
+	if schedule.GreedyScore < 2*imp.DefaultRankWeights.Unseated() {
+		t.Fatalf("greedy scored %g, want the freshman left without the full day course", schedule.GreedyScore)
+	}
+	if unseated(schedule) != 0 || schedule.Score != 2 {
+		t.Errorf("annealing scored %g with %d unseated slots, want 2 with the junior in X and P", schedule.Score, unseated(schedule))
+	}
+}
+
+func TestAnnealOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end float64
+		valid      bool
+	}{
+		{"defaults", DefaultAnnealOptions.StartTemperature, DefaultAnnealOptions.EndTemperature, true},
+		{"zero start", 0, 0.05, false},
+		{"negative start", -1, 0.05, false},
+		{"zero end", 4, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := AnnealOptions{StartTemperature: test.start, EndTemperature: test.end, Steps: 10}.Validate()
+			if (err == nil) != test.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// ErrKeptApart reports that a run found no schedule that meets the keep-apart constraints. Its message
+// names no student, so it can be shown to whoever asked for the schedule.
+var ErrKeptApart = errors.New("no schedule meets the keep-apart constraints")
+
+// KeptApartError is an ErrKeptApart that carries the students and the lines of the keep-apart file
+// involved in Detail. The file is confidential, so Detail is only meant for the log of whoever runs
+// the scheduler and Error doesn't include it.
+type KeptApartError struct {
+	Detail string
+}
+
+func (e *KeptApartError) Error() string {
+	return ErrKeptApart.Error()
+}
+
+func (e *KeptApartError) Unwrap() error {
+	return ErrKeptApart
+}
+
+// apartMate is a student that another student must not share a course with
+type apartMate struct {
+	email string
+	// line is the line of the keep-apart file that keeps the students apart
+	line int
+}
+
+// resolveKeepApart indexes the keep-apart constraints by the normalized email of every student they name
+func (s *Scheduler) resolveKeepApart() {
+	s.apart = make(map[string][]apartMate)
+	for _, constraint := range s.DataLoader.KeptApart {
+		for _, email := range constraint.Emails {
+			for _, other := range constraint.Emails {
+				if other != email {
+					s.apart[data.NormalizeEmail(email)] = append(s.apart[data.NormalizeEmail(email)], apartMate{email: data.NormalizeEmail(other), line: constraint.Line})
+				}
+			}
+		}
+	}
+}
+
+// mateIn returns the keep-apart constraint of the student with someone enrolled in the sections, ignoring
+// the given student, who is about to leave them. It returns false when the student may join the sections.
+func (s *Scheduler) mateIn(student *imp.Student, sections []*imp.Section, ignore *imp.Student) (apartMate, bool) {
+	mates := s.apart[data.NormalizeEmail(student.StudentEmail)]
+	if len(mates) == 0 {
+		return apartMate{}, false
+	}
+	for _, section := range sections {
+		for _, other := range section.Students {
+			if other == ignore || other == student {
+				continue
+			}
+			email := data.NormalizeEmail(other.StudentEmail)
+			for _, mate := range mates {
+				if mate.email == email {
+					return mate, true
+				}
+			}
+		}
+	}
+	return apartMate{}, false
+}
+
+// keptApart reports whether the student must stay out of the course because of someone enrolled in it
+func (s *Scheduler) keptApart(student *imp.Student, courseName string) bool {
+	_, found := s.mateIn(student, s.CourseNameToSections[courseName], nil)
+	return found
+}
+
+// apartIndexes lists for every student the positions of the students they must be kept apart from
+func (s *Scheduler) apartIndexes(students []*imp.Student) [][]int {
+	if len(s.apart) == 0 {
+		return nil
+	}
+	positions := make(map[string]int, len(students))
+	for i, student := range students {
+		positions[data.NormalizeEmail(student.StudentEmail)] = i
+	}
+	indexes := make([][]int, len(students))
+	for i, student := range students {
+		for _, mate := range s.apart[data.NormalizeEmail(student.StudentEmail)] {
+			if j, ok := positions[mate.email]; ok {
+				indexes[i] = append(indexes[i], j)
+			}
+		}
+	}
+	return indexes
+}
+
+// keptApartError checks that no students who must be kept apart share a course, and that no student was
+// left without a seat in a time slot only because every course with room has someone they must be
+// kept apart from. The error is a KeptApartError whose detail names the constraint so it can be reviewed.
+func (s *Scheduler) keptApartError(students []*imp.Student, sections []*imp.Section) error {
+	if len(s.apart) == 0 {
+		return nil
+	}
+	byCourse := make(map[string][]*imp.Section)
+	for _, section := range sections {
+		byCourse[section.Course.CourseName] = append(byCourse[section.Course.CourseName], section)
+	}
+	for _, student := range students {
+		if len(s.apart[data.NormalizeEmail(student.StudentEmail)]) == 0 {
+			continue
+		}
+		for _, timeSlot := range s.timeSlots() {
+			course := student.EnrolledCourses[timeSlot]
+			if course.CourseName != "" {
+				if mate, found := s.mateIn(student, byCourse[course.CourseName], nil); found {
+					return &KeptApartError{Detail: fmt.Sprintf("%s and %s are both in %q, which line %d of %s forbids", student.StudentEmail, mate.email, course.CourseName, mate.line, s.DataLoader.ApartSource())}
+				}
+				continue
+			}
+			var blocking []apartMate
+			blocked := false
+			for _, section := range sections {
+				if section.Course.TimeSlot != timeSlot || len(section.Students) >= section.MaxStudents || !section.Course.IsEligible(student.Grade) {
+					continue
+				}
+				mate, found := s.mateIn(student, byCourse[section.Course.CourseName], nil)
+				if !found {
+					blocked = false
+					break
+				}
+				blocked = true
+				blocking = append(blocking, mate)
+			}
+			if blocked {
+				var lines []string
+				seen := make(map[int]bool)
+				for _, mate := range blocking {
+					if !seen[mate.line] {
+						seen[mate.line] = true
+						lines = append(lines, fmt.Sprintf("%d", mate.line))
+					}
+				}
+				label := "line"
+				if len(lines) > 1 {
+					label = "lines"
+				}
+				return &KeptApartError{Detail: fmt.Sprintf("%s can't be seated in the %s because every %s course with room has a student they must be kept apart from (%s %s of %s)", student.StudentEmail, timeSlot, timeSlot, label, strings.Join(lines, ", "), s.DataLoader.ApartSource())}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+// newApartScheduler loads a scheduler that keeps a@x.org and b@x.org apart, as line 2 of secret.csv asks
+func newApartScheduler(t *testing.T, requests, events string) *Scheduler {
+	t.Helper()
+	options := data.Options{
+		Apart:     []data.KeepApart{{Emails: []string{"a@x.org", "B@x.org"}, Line: 2}},
+		ApartPath: "secret.csv",
+	}
+	loader, err := data.Load([]byte(requests), []byte(events), options)
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	s, err := NewSchedulerWithData(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Workers, s.Seed = 1, 1
+	s.Anneal = &AnnealOptions{StartTemperature: 4, EndTemperature: 0.05, Steps: 20000}
+	s.Progress = &Progress{}
+	return s
+}
+
+func TestKeptApartStudentsNeverShareACourse(t *testing.T) {
+	// Both students want X first, where there is room for both, so one of them takes Y
+	requests := choiceHeaders +
+		"a@x.org,A,A,Junior,X,Y,,\n" +
+		"b@x.org,B,B,Junior,X,Y,,\n"
+	events := "Name,Max Students,Time Slot\nX,2,AM\nY,2,AM\n"
+	for _, strategy := range []Strategy{GreedyStrategy{}, OptimalStrategy{}, AnnealStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			s := newApartScheduler(t, requests, events)
+			schedule := strategy.Schedule(s, 10)
+			if schedule == nil {
+				t.Fatal("no schedule, want one with the students apart")
+			}
+			if err := s.keptApartError(schedule.Students, schedule.Sections); err != nil {
+				t.Errorf("keptApartError() = %v", err)
+			}
+			if unseated(schedule) != 0 || schedule.Score != 1 {
+				t.Errorf("scored %g with %d unseated slots, want a second choice for one of them", schedule.Score, unseated(schedule))
+			}
+		})
+	}
+}
+
+func TestKeepApartFailureHidesTheStudents(t *testing.T) {
+	// X is the only course, so the second student to be seated has nowhere to go
+	requests := choiceHeaders +
+		"a@x.org,A,A,Junior,X,,,\n" +
+		"b@x.org,B,B,Junior,X,,,\n"
+	s := newApartScheduler(t, requests, "Name,Max Students,Time Slot\nX,2,AM\n")
+	s.Strategy = GreedyStrategy{}
+	_, err := s.RunContext(context.Background(), 5)
+	if !errors.Is(err, ErrKeptApart) {
+		t.Fatalf("RunContext() error = %v, want %v", err, ErrKeptApart)
+	}
+	if strings.Contains(err.Error(), "x.org") || strings.Contains(err.Error(), "secret.csv") {
+		t.Errorf("the error %q reveals the keep-apart constraint", err)
+	}
+	var apartErr *KeptApartError
+	if !errors.As(err, &apartErr) || !strings.Contains(apartErr.Detail, "@x.org") || !strings.Contains(apartErr.Detail, "line 2 of secret.csv") {
+		t.Errorf("the detail of %v doesn't name the student and line 2 of secret.csv", err)
+	}
+}
@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// balanceRules returns the balance rules of the loaded data
+func (s *Scheduler) balanceRules() []data.BalanceRule {
+	if s.DataLoader.Balance == nil {
+		return nil
+	}
+	return s.DataLoader.Balance.Rules
+}
+
+// hasHardBalance reports whether any balance rule must never be broken
+func (s *Scheduler) hasHardBalance() bool {
+	for _, rule := range s.balanceRules() {
+		if rule.Hard {
+			return true
+		}
+	}
+	return false
+}
+
+func attributeOf(student *imp.Student, attribute string) string {
+	if student.RequestedCourses == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(data.Attribute(student.RequestedCourses, attribute)))
+}
+
+// countValue counts the students of the section with the value of the attribute, leaving out ignore
+func countValue(section *imp.Section, attribute, value string, ignore *imp.Student) int {
+	count := 0
+	for _, student := range section.Students {
+		if student != ignore && attributeOf(student, attribute) == value {
+			count++
+		}
+	}
+	return count
+}
+
+// balanceAllows reports whether the hard balance rules let the student join the section, leaving out the
+// given student, who is about to leave it
+func (s *Scheduler) balanceAllows(student *imp.Student, section *imp.Section, ignore *imp.Student) bool {
+	for _, rule := range s.balanceRules() {
+		if !rule.Hard || !rule.AppliesTo(section.Course.CourseName) {
+			continue
+		}
+		limit := rule.Limit(section.MaxStudents)
+		value := attributeOf(student, rule.Attribute)
+		if limit < 0 || value == "" || (rule.Value != "" && value != strings.ToLower(strings.TrimSpace(rule.Value))) {
+			continue
+		}
+		if countValue(section, rule.Attribute, value, ignore)+1 > limit {
+			return false
+		}
+	}
+	return true
+}
+
+// openSection returns the least filled section with room that the balance rules let the student join,
+// or nil when there is none
+func (s *Scheduler) openSection(student *imp.Student, sections []*imp.Section) *imp.Section {
+	if !s.hasHardBalance() {
+		return leastFilledSection(sections)
+	}
+	var allowed []*imp.Section
+	for _, section := range sections {
+		if s.balanceAllows(student, section, nil) {
+			allowed = append(allowed, section)
+		}
+	}
+	return leastFilledSection(allowed)
+}
+
+// balanceIssue is a balance rule a section breaks, with the number of students it is off by
+type balanceIssue struct {
+	rule    data.BalanceRule
+	value   string
+	count   int
+	limit   int
+	under   bool
+	penalty float64
+}
+
+func (i balanceIssue) String() string {
+	if i.under {
+		return fmt.Sprintf("%d %s %s under the minimum of %d", i.count, i.rule.Attribute, i.value, i.limit)
+	}
+	return fmt.Sprintf("%d %s %s over the maximum of %d", i.count, i.rule.Attribute, i.value, i.limit)
+}
+
+// balanceIssues lists the balance rules the section breaks
+func (s *Scheduler) balanceIssues(section *imp.Section) []balanceIssue {
+	var issues []balanceIssue
+	for _, rule := range s.balanceRules() {
+		if !rule.AppliesTo(section.Course.CourseName) {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, student := range section.Students {
+			if value := attributeOf(student, rule.Attribute); value != "" {
+				counts[value]++
+			}
+		}
+		var values []string
+		if rule.Value != "" {
+			values = []string{strings.ToLower(strings.TrimSpace(rule.Value))}
+		} else {
+			for value := range counts {
+				values = append(values, value)
+			}
+			sort.Strings(values)
+		}
+		limit := rule.Limit(section.MaxStudents)
+		for _, value := range values {
+			count := counts[value]
+			if limit >= 0 && count > limit {
+				issues = append(issues, balanceIssue{rule: rule, value: value, count: count, limit: limit, penalty: rule.Penalty() * float64(count-limit)})
+			}
+			if count < rule.Min {
+				issues = append(issues, balanceIssue{rule: rule, value: value, count: count, limit: rule.Min, under: true, penalty: rule.Penalty() * float64(rule.Min-count)})
+			}
+		}
+	}
+	return issues
+}
+
+// balancePenalty returns what the balance rules the sections break add to the score
+func (s *Scheduler) balancePenalty(sections ...*imp.Section) float64 {
+	if len(s.balanceRules()) == 0 {
+		return 0
+	}
+	penalty := 0.0
+	for _, section := range sections {
+		for _, issue := range s.balanceIssues(section) {
+			penalty += issue.penalty
+		}
+	}
+	return penalty
+}
+
+// balanceAttributes lists the attributes the sections output shows the composition of, the grade first
+func (s *Scheduler) balanceAttributes() []string {
+	attributes := []string{data.GradeAttribute}
+	seen := map[string]bool{data.GradeAttribute: true}
+	for _, rule := range s.balanceRules() {
+		attribute := strings.ToLower(strings.TrimSpace(rule.Attribute))
+		if !seen[attribute] {
+			seen[attribute] = true
+			attributes = append(attributes, rule.Attribute)
+		}
+	}
+	return attributes
+}
+
+// composition counts the students of the section by their value of the attribute, such as
+// "Freshman: 8, Junior: 10"
+func composition(section *imp.Section, attribute string) string {
+	counts := make(map[string]int)
+	names := make(map[string]string)
+	for _, student := range section.Students {
+		value := strings.TrimSpace(data.Attribute(student.RequestedCourses, attribute))
+		if value == "" {
+			value = "unknown"
+		}
+		counts[strings.ToLower(value)]++
+		if _, ok := names[strings.ToLower(value)]; !ok {
+			names[strings.ToLower(value)] = value
+		}
+	}
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%s: %d", names[value], counts[value])
+	}
+	return strings.Join(parts, ", ")
+}
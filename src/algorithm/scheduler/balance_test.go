@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+// All four freshmen want X first, but no grade may take more than half of the four seats of a section
+const balanceRequests = choiceHeaders +
+	"a@x.org,A,A,Freshman,X,Y,,\n" +
+	"b@x.org,B,B,Freshman,X,Y,,\n" +
+	"c@x.org,C,C,Freshman,X,Y,,\n" +
+	"d@x.org,D,D,Freshman,X,Y,,\n"
+
+const balanceEvents = "Name,Max Students,Time Slot\nX,4,AM\nY,4,AM\n"
+
+func newBalanceScheduler(t *testing.T, hard bool) *Scheduler {
+	t.Helper()
+	rules := &data.BalanceRules{Rules: []data.BalanceRule{{Attribute: "Grade", MaxShare: 0.5, Hard: hard}}}
+	loader, err := data.Load([]byte(balanceRequests), []byte(balanceEvents), data.Options{Balance: rules})
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	s, err := NewSchedulerWithData(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Workers, s.Seed = 1, 1
+	s.Anneal = &AnnealOptions{StartTemperature: 4, EndTemperature: 0.05, Steps: 20000}
+	return s
+}
+
+func TestHardBalanceRulesAreNeverBroken(t *testing.T) {
+	for _, strategy := range []Strategy{GreedyStrategy{}, OptimalStrategy{}, AnnealStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			schedule := strategy.Schedule(newBalanceScheduler(t, true), 5)
+			for _, section := range schedule.Sections {
+				if got := composition(section, data.GradeAttribute); got != "Freshman: 2" {
+					t.Errorf("%s holds %s, want 2 freshmen", section.Course.CourseName, got)
+				}
+			}
+			if unseated(schedule) != 0 || schedule.Score != 2 {
+				t.Errorf("scored %g with %d unseated slots, want two students in their second choice", schedule.Score, unseated(schedule))
+			}
+		})
+	}
+}
+
+func TestSoftBalanceRulesArePenalized(t *testing.T) {
+	s := newBalanceScheduler(t, false)
+	crowded := s.CourseNameToSections["X"][0]
+	for _, student := range s.DataLoader.Students {
+		crowded.AddStudent(student)
+	}
+	issues := s.balanceIssues(crowded)
+	if len(issues) != 1 || issues[0].String() != "4 Grade freshman over the maximum of 2" || s.balancePenalty(crowded) != 2*data.DefaultBalanceWeight {
+		t.Errorf("a section of 4 freshmen breaks %v for a penalty of %g, want 2 students over the maximum", issues, s.balancePenalty(crowded))
+	}
+	crowded.ClearStudents()
+
+	// The flow of the optimal strategy leaves soft rules to be penalized, annealing trades the penalty away
+	if optimal := (OptimalStrategy{}).Schedule(s, 5); optimal.Optimal {
+		t.Error("the optimal strategy claims a proven optimum under balance rules it doesn't model")
+	}
+	if schedule := (AnnealStrategy{}).Schedule(newBalanceScheduler(t, false), 5); schedule.Score != 2 {
+		t.Errorf("annealing scored %g, want two second choices rather than a penalty", schedule.Score)
+	}
+}
@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"encoding/csv"
+	"fmt"
+
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// Cancellation records a section that was cancelled for having too few students
+type Cancellation struct {
+	Course      string `json:"course"`
+	Section     int    `json:"section"`
+	TimeSlot    string `json:"timeSlot"`
+	MinStudents int    `json:"minStudents"`
+	Enrolled    int    `json:"enrolled"`
+	// Round is the run of the strategy, counted from one, whose schedule had the section under-enrolled
+	Round  int    `json:"round"`
+	Reason string `json:"reason"`
+}
+
+// scheduleWithCancellations runs the strategy until no section is below its minimum enrollment. After
+// every run it cancels the section that is furthest below its minimum and runs the strategy again without
+// it, so its students are placed in another section of the course or in their next choices. Every run
+// reports its own progress. The cancelled sections are only left out for the duration of the call.
+func (s *Scheduler) scheduleWithCancellations(strategy Strategy, numIterations int) *Schedule {
+	sections := s.CourseNameToSections
+	defer func() {
+		s.CourseNameToSections = sections
+		s.sectionOrder = nil
+	}()
+
+	var cancelled []Cancellation
+	for round := 1; ; round++ {
+		schedule := strategy.Schedule(s, numIterations)
+		if schedule == nil || s.stopped() {
+			return schedule
+		}
+		section := s.mostUnderEnrolled(schedule.Sections)
+		if section == nil {
+			schedule.Cancelled = cancelled
+			return schedule
+		}
+		cancelled = append(cancelled, Cancellation{
+			Course:      section.Course.CourseName,
+			Section:     section.Course.Section,
+			TimeSlot:    section.Course.TimeSlot,
+			MinStudents: section.MinStudents,
+			Enrolled:    len(section.Students),
+			Round:       round,
+			Reason:      fmt.Sprintf("only %d of the required %d students could be enrolled", len(section.Students), section.MinStudents),
+		})
+
+		remaining := make(map[string][]*imp.Section, len(s.CourseNameToSections))
+		for courseName, courseSections := range s.CourseNameToSections {
+			for _, other := range courseSections {
+				if !other.Equals(section) {
+					remaining[courseName] = append(remaining[courseName], other)
+				}
+			}
+		}
+		s.CourseNameToSections = remaining
+		s.sectionOrder = nil
+		s.BestSchedule = nil
+		if s.bar != nil {
+			s.bar.Reset()
+		}
+		if s.Progress != nil {
+			s.Progress.start(numIterations)
+		}
+	}
+}
+
+// mostUnderEnrolled returns the section with the lowest share of its minimum enrollment, or nil when
+// every section has enough students. Sections with locked seats are never cancelled. Ties go to the first
+// section, which keeps the result reproducible.
+func (s *Scheduler) mostUnderEnrolled(sections []*imp.Section) *imp.Section {
+	var worst *imp.Section
+	for _, section := range sections {
+		if !section.UnderEnrolled() || s.lockedSections[keyOf(section.Course)] > 0 {
+			continue
+		}
+		if worst == nil || len(section.Students)*worst.MinStudents < len(worst.Students)*section.MinStudents {
+			worst = section
+		}
+	}
+	return worst
+}
+
+func outputCancellations(writer *csv.Writer, cancelled []Cancellation) error {
+	if err := writer.Write([]string{"Course Name", "Section", "Time Slot", "Min Students", "Enrolled Students", "Round", "Reason"}); err != nil {
+		return err
+	}
+	for _, cancellation := range cancelled {
+		record := []string{
+			cancellation.Course,
+			fmt.Sprintf("%d", cancellation.Section),
+			cancellation.TimeSlot,
+			fmt.Sprintf("%d", cancellation.MinStudents),
+			fmt.Sprintf("%d", cancellation.Enrolled),
+			fmt.Sprintf("%d", cancellation.Round),
+			cancellation.Reason,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Only two students want X, which needs three, so cancelling it moves them to their second choice Y.
+// Z needs two as well but has them once X is gone.
+const underEnrolledRequests = choiceHeaders +
+	"a@x.org,A,A,Junior,X,Y,Z,\n" +
+	"b@x.org,B,B,Junior,X,Y,Z,\n" +
+	"c@x.org,C,C,Junior,Y,,P,\n"
+
+const underEnrolledEvents = "Name,Max Students,Time Slot,Min Students\n" +
+	"X,5,AM,3\n" +
+	"Y,5,AM,\n" +
+	"Z,5,PM,2\n" +
+	"P,5,PM,\n"
+
+func TestUnderEnrolledSectionsAreCancelled(t *testing.T) {
+	for _, strategy := range []Strategy{GreedyStrategy{}, OptimalStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			s := newTestScheduler(t, underEnrolledRequests, underEnrolledEvents)
+			schedule := s.scheduleWithCancellations(strategy, 5)
+			want := []Cancellation{{
+				Course: "X", Section: 1, TimeSlot: "AM", MinStudents: 3, Enrolled: 2, Round: 1,
+				Reason: "only 2 of the required 3 students could be enrolled",
+			}}
+			if !reflect.DeepEqual(schedule.Cancelled, want) {
+				t.Errorf("cancelled %+v, want %+v", schedule.Cancelled, want)
+			}
+			if unseated(schedule) != 0 || schedule.Score != 2 {
+				t.Errorf("scored %g with %d unseated slots, want a second choice for the students of X", schedule.Score, unseated(schedule))
+			}
+			if len(s.CourseNameToSections["X"]) != 1 {
+				t.Error("the cancelled section is still left out after the run")
+			}
+		})
+	}
+}
+
+func TestUnderEnrolledSectionsAreKeptUnlessCancelling(t *testing.T) {
+	s := newTestScheduler(t, underEnrolledRequests, underEnrolledEvents)
+	schedule := GreedyStrategy{}.Schedule(s, 5)
+	if len(schedule.Cancelled) != 0 || schedule.Score != 0 {
+		t.Errorf("scored %g and cancelled %+v, want everyone in their first choice", schedule.Score, schedule.Cancelled)
+	}
+}
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// GreedyStrategy shuffles the students by priority, fills the sections greedily
+// and keeps the best scoring schedule over all iterations.
+type GreedyStrategy struct{}
+
+func init() {
+	RegisterStrategy(GreedyStrategy{})
+}
+
+func (GreedyStrategy) Name() string {
+	return "greedy"
+}
+
+func (GreedyStrategy) Schedule(s *Scheduler, numIterations int) *Schedule {
+	if s.Workers <= 1 {
+		for i := 0; i < numIterations && !s.stopped(); i++ {
+			s.runTrial(i)
+		}
+		return s.BestSchedule
+	}
+
+	// Each worker runs its share of the trials on its own copy of the students and sections
+	trials := make(chan int)
+	results := make([]*Schedule, s.Workers)
+	infeasible := make([]error, s.Workers)
+	var wg sync.WaitGroup
+	for w := 0; w < s.Workers; w++ {
+		wg.Add(1)
+		go func(w int, worker *Scheduler) {
+			defer wg.Done()
+			for trial := range trials {
+				worker.runTrial(trial)
+			}
+			results[w] = worker.BestSchedule
+			infeasible[w] = worker.infeasible
+		}(w, s.clone())
+	}
+	for i := 0; i < numIterations && !s.stopped(); i++ {
+		trials <- i
+	}
+	close(trials)
+	wg.Wait()
+
+	// Break ties on the trial number so the result matches a sequential run with the same seed
+	var best *Schedule
+	for _, result := range results {
+		if result != nil && (best == nil || result.Score < best.Score || (result.Score == best.Score && result.Trial < best.Trial)) {
+			best = result
+		}
+	}
+	if best != nil && (s.BestSchedule == nil || best.Score < s.BestSchedule.Score) {
+		s.BestSchedule = best
+	}
+	for _, err := range infeasible {
+		if err != nil {
+			s.infeasible = err
+		}
+	}
+	return s.BestSchedule
+}
+
+// runTrial shuffles and fills the sections once with a random source derived from the seed and
+// the trial number, keeping the result if it beats the best schedule so far. A trial that left a student
+// unseated because of a keep-apart constraint is dropped, since another order may seat them.
+func (s *Scheduler) runTrial(trial int) {
+	s.tick()
+	s.trial = trial
+	s.rng = rand.New(rand.NewSource(s.Seed + int64(trial)))
+	s.ExtractByGradeAndShuffle()
+	s.AssignStudentsToSections()
+	if err := s.keptApartError(s.DataLoader.Students, s.sortedSections()); err != nil {
+		s.infeasible = err
+	} else {
+		s.ScoreSchedule()
+	}
+	s.ClearSections()
+}
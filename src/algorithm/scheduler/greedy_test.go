@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Students list three AM choices but a single PM one. Only one of them fits in X and in Y, so the
+// order the trials seat them in decides who settles for a later choice.
+const contestedRequests = "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+	"AM Course - 1st Choice. (Drop down option),AM Course - 2nd Choice. (Drop down option),AM Course - 3rd Choice. (Drop down option)," +
+	"PM Course - 1st Choice. (Drop down option)\n" +
+	"a@x.org,A,A,Junior,X,Y,Z,P\n" +
+	"b@x.org,B,B,Junior,X,Z,Y,P\n" +
+	"c@x.org,C,C,Junior,Y,X,Z,P\n" +
+	"d@x.org,D,D,Junior,Z,Y,X,Q\n"
+
+const contestedEvents = "Name,Max Students,Time Slot\nX,1,AM\nY,1,AM\nZ,2,AM\nP,2,PM\nQ,2,PM\n"
+
+// enrollments describes the courses of every student of the schedule
+func enrollments(schedule *Schedule) map[string]string {
+	enrolled := make(map[string]string, len(schedule.Students))
+	for _, student := range schedule.Students {
+		enrolled[student.StudentEmail] = fmt.Sprint(student.EnrolledCourses)
+	}
+	return enrolled
+}
+
+func TestWorkersMatchTheSequentialRun(t *testing.T) {
+	for seed := int64(1); seed <= 4; seed++ {
+		sequential := newTestScheduler(t, contestedRequests, contestedEvents)
+		sequential.Seed = seed
+		want := GreedyStrategy{}.Schedule(sequential, 20)
+		for _, workers := range []int{2, 3, 8} {
+			t.Run(fmt.Sprintf("seed %d on %d workers", seed, workers), func(t *testing.T) {
+				s := newTestScheduler(t, contestedRequests, contestedEvents)
+				s.Seed = seed
+				s.Workers = workers
+				got := GreedyStrategy{}.Schedule(s, 20)
+				if got.Score != want.Score || got.Trial != want.Trial {
+					t.Errorf("scored %g in trial %d, want %g in trial %d", got.Score, got.Trial, want.Score, want.Trial)
+				}
+				wantEnrolled := enrollments(want)
+				for email, courses := range enrollments(got) {
+					if courses != wantEnrolled[email] {
+						t.Errorf("%s is enrolled in %s, want %s", email, courses, wantEnrolled[email])
+					}
+				}
+			})
+		}
+	}
+}
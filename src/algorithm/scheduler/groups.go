@@ -0,0 +1,299 @@
+package scheduler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// DefaultGroupWeight is what every student placed apart from the rest of their group adds to the score,
+// as much as getting a third instead of a first choice under the default rank weights
+const DefaultGroupWeight = 2.0
+
+// GroupResult reports whether the students of a group request share a section in a time slot
+type GroupResult struct {
+	Line     int      `json:"line"`
+	TimeSlot string   `json:"timeSlot"`
+	Emails   []string `json:"emails"`
+	Honored  bool     `json:"honored"`
+	// Apart counts the students who are not in the section most of the group shares
+	Apart int `json:"apart"`
+	// Sections names the section of every student, in the order of Emails
+	Sections []string `json:"sections"`
+}
+
+// togetherGroup is a group request for one time slot, with the normalized emails of its students
+type togetherGroup struct {
+	emails   []string
+	timeSlot string
+	request  data.Group
+}
+
+// resolveGroups splits the group requests that cover several time slots into one for each slot
+func (s *Scheduler) resolveGroups() {
+	s.groups = nil
+	s.groupsOf = make(map[string][]int)
+	for _, request := range s.DataLoader.TogetherGroups {
+		timeSlots := request.Slots
+		if len(timeSlots) == 0 {
+			timeSlots = s.timeSlots()
+		}
+		emails := make([]string, len(request.Emails))
+		for i, email := range request.Emails {
+			emails[i] = data.NormalizeEmail(email)
+		}
+		for _, timeSlot := range timeSlots {
+			for _, email := range emails {
+				s.groupsOf[email] = append(s.groupsOf[email], len(s.groups))
+			}
+			s.groups = append(s.groups, togetherGroup{emails: emails, timeSlot: timeSlot, request: request})
+		}
+	}
+}
+
+// studentsByEmail indexes the students by their normalized email, or returns nil when there are no groups
+func (s *Scheduler) studentsByEmail(students []*imp.Student) map[string]*imp.Student {
+	if len(s.groups) == 0 {
+		return nil
+	}
+	byEmail := make(map[string]*imp.Student, len(students))
+	for _, student := range students {
+		byEmail[data.NormalizeEmail(student.StudentEmail)] = student
+	}
+	return byEmail
+}
+
+// apart returns the section most of the group shares and the number of its students who are elsewhere.
+// Unseated students are always apart.
+func (g *togetherGroup) apart(byEmail map[string]*imp.Student) (sectionKey, int) {
+	counts := make(map[sectionKey]int)
+	var shared sectionKey
+	for _, email := range g.emails {
+		student := byEmail[email]
+		if student == nil {
+			continue
+		}
+		course := student.EnrolledCourses[g.timeSlot]
+		if course.CourseName == "" {
+			continue
+		}
+		key := keyOf(&course)
+		counts[key]++
+		if counts[key] > counts[shared] || counts[key] == counts[shared] && (key.course < shared.course || key.course == shared.course && key.section < shared.section) {
+			shared = key
+		}
+	}
+	return shared, len(g.emails) - counts[shared]
+}
+
+// groupPenalty returns what the given groups add to the score of the students
+func (s *Scheduler) groupPenalty(groups []int, byEmail map[string]*imp.Student) float64 {
+	penalty := 0.0
+	for _, i := range groups {
+		_, apart := s.groups[i].apart(byEmail)
+		penalty += s.GroupWeight * float64(apart)
+	}
+	return penalty
+}
+
+// schedulePenalty returns what all groups add to the score of the students
+func (s *Scheduler) schedulePenalty(students []*imp.Student) float64 {
+	if len(s.groups) == 0 {
+		return 0
+	}
+	all := make([]int, len(s.groups))
+	for i := range all {
+		all[i] = i
+	}
+	return s.groupPenalty(all, s.studentsByEmail(students))
+}
+
+// groupsAffected returns the groups of the students that a move between sections of the course can change
+func (s *Scheduler) groupsAffected(course *imp.Course, students ...*imp.Student) []int {
+	var affected []int
+	seen := make(map[int]bool)
+	for _, student := range students {
+		if student == nil {
+			continue
+		}
+		for _, i := range s.groupsOf[data.NormalizeEmail(student.StudentEmail)] {
+			if !seen[i] && course.Occupies(s.groups[i].timeSlot) {
+				seen[i] = true
+				affected = append(affected, i)
+			}
+		}
+	}
+	return affected
+}
+
+// hasGroup reports whether the student asked to be placed with others
+func (s *Scheduler) hasGroup(student *imp.Student) bool {
+	return len(s.groupsOf[data.NormalizeEmail(student.StudentEmail)]) > 0
+}
+
+// keepGroupsTogether moves the students of a group up behind the first of them in the order, so
+// that they are seated one after another and find the same sections open
+func (s *Scheduler) keepGroupsTogether(students []*imp.Student) []*imp.Student {
+	if len(s.groups) == 0 {
+		return students
+	}
+	placed := make(map[*imp.Student]bool, len(students))
+	byEmail := s.studentsByEmail(students)
+	ordered := make([]*imp.Student, 0, len(students))
+	for _, student := range students {
+		if placed[student] {
+			continue
+		}
+		placed[student] = true
+		ordered = append(ordered, student)
+		for _, i := range s.groupsOf[data.NormalizeEmail(student.StudentEmail)] {
+			for _, email := range s.groups[i].emails {
+				if mate := byEmail[email]; mate != nil && !placed[mate] {
+					placed[mate] = true
+					ordered = append(ordered, mate)
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// gatherGroups moves students into the section of another student of their group whenever that lowers
+// the score. When the section is full, a student without a group who may take the other section makes
+// room by swapping with them.
+func (s *Scheduler) gatherGroups() {
+	if len(s.groups) == 0 || s.GroupWeight <= 0 {
+		return
+	}
+	sections := make(map[sectionKey]*imp.Section, len(s.sortedSections()))
+	for _, section := range s.sortedSections() {
+		sections[keyOf(section.Course)] = section
+	}
+	byEmail := s.studentsByEmail(s.DataLoader.Students)
+	sectionOf := func(student *imp.Student, timeSlot string) *imp.Section {
+		course := student.EnrolledCourses[timeSlot]
+		return sections[keyOf(&course)]
+	}
+	for improved := true; improved; {
+		improved = false
+		for _, group := range s.groups {
+			if _, apart := group.apart(byEmail); apart == 0 {
+				continue
+			}
+			for _, email := range group.emails {
+				to := sectionOf(byEmail[email], group.timeSlot)
+				if to == nil || to.Course.MultiSlot() {
+					continue
+				}
+				for _, mate := range group.emails {
+					if s.joinSection(byEmail[mate], sectionOf(byEmail[mate], group.timeSlot), to, byEmail) {
+						improved = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// joinSection moves the student from one section to another of the same time slot if that lowers
+// the score, swapping with a student without a group when the section is full, and reports whether it did
+func (s *Scheduler) joinSection(student *imp.Student, from, to *imp.Section, byEmail map[string]*imp.Student) bool {
+	if from == nil || from == to || from.Course.MultiSlot() || s.isLocked(student, from) || !to.Course.IsEligible(student.Grade) {
+		return false
+	}
+	if from.Course.CourseName != to.Course.CourseName && s.keptApart(student, to.Course.CourseName) {
+		return false
+	}
+	affected := s.groupsAffected(from.Course, student)
+	before := s.groupPenalty(affected, byEmail) + s.balancePenalty(from, to)
+	delta := student.CourseCost(to.Course) - student.CourseCost(from.Course)
+	if len(to.Students) < to.MaxStudents {
+		if !s.balanceAllows(student, to, nil) {
+			return false
+		}
+		s.moveStudent(student, from, to)
+		if delta+s.groupPenalty(affected, byEmail)+s.balancePenalty(from, to)-before < -1e-9 {
+			return true
+		}
+		s.moveStudent(student, to, from)
+		return false
+	}
+	for _, other := range append([]*imp.Student(nil), to.Students...) {
+		if s.hasGroup(other) || s.isLocked(other, to) || !from.Course.IsEligible(other.Grade) ||
+			!s.balanceAllows(student, to, other) || !s.balanceAllows(other, from, student) {
+			continue
+		}
+		if _, found := s.mateIn(other, s.CourseNameToSections[from.Course.CourseName], student); found && from.Course.CourseName != to.Course.CourseName {
+			continue
+		}
+		swapDelta := delta + other.CourseCost(from.Course) - other.CourseCost(to.Course)
+		s.moveStudent(other, to, from)
+		s.moveStudent(student, from, to)
+		if swapDelta+s.groupPenalty(affected, byEmail)+s.balancePenalty(from, to)-before < -1e-9 {
+			return true
+		}
+		s.moveStudent(student, to, from)
+		s.moveStudent(other, from, to)
+	}
+	return false
+}
+
+func (s *Scheduler) moveStudent(student *imp.Student, from, to *imp.Section) {
+	from.RemoveStudent(student)
+	student.RemoveEnrolledCourse(from.Course)
+	to.AddStudent(student)
+	student.AddEnrolledCourse(to.Course)
+}
+
+// groupResults reports on every group request of the schedule
+func (s *Scheduler) groupResults(students []*imp.Student) []GroupResult {
+	byEmail := s.studentsByEmail(students)
+	results := make([]GroupResult, 0, len(s.groups))
+	for _, group := range s.groups {
+		_, apart := group.apart(byEmail)
+		result := GroupResult{
+			Line:     group.request.Line,
+			TimeSlot: group.timeSlot,
+			Emails:   group.request.Emails,
+			Honored:  apart == 0,
+			Apart:    apart,
+		}
+		for _, email := range group.emails {
+			course := byEmail[email].EnrolledCourses[group.timeSlot]
+			result.Sections = append(result.Sections, sectionName(course))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// sectionName names the course and section of an enrollment, or is empty when the student is unseated
+func sectionName(course imp.Course) string {
+	if course.CourseName == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (section %d)", course.CourseName, course.Section)
+}
+
+func outputGroups(writer *csv.Writer, results []GroupResult) error {
+	if err := writer.Write([]string{"Line", "Time Slot", "Students", "Honored", "Students Apart", "Sections"}); err != nil {
+		return err
+	}
+	for _, result := range results {
+		record := []string{
+			fmt.Sprintf("%d", result.Line),
+			result.TimeSlot,
+			strings.Join(result.Emails, ", "),
+			fmt.Sprintf("%t", result.Honored),
+			fmt.Sprintf("%d", result.Apart),
+			strings.Join(result.Sections, "; "),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
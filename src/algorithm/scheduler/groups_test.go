@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+// The three students who want to share an AM section don't fit in any of them, while the pair who
+// want to share a PM section does
+const groupRequests = choiceHeaders +
+	"a@x.org,A,A,Junior,X,Y,P,Q\n" +
+	"b@x.org,B,B,Junior,X,Y,P,Q\n" +
+	"c@x.org,C,C,Junior,X,Y,Q,\n"
+
+const groupEvents = "Name,Max Students,Time Slot\nX,2,AM\nY,2,AM\nP,2,PM\nQ,2,PM\n"
+
+func TestGroupsAreReportedHonoredOrApart(t *testing.T) {
+	groups := []data.Group{
+		{Emails: []string{"a@x.org", "b@x.org", "c@x.org"}, TimeSlot: "am", Line: 2},
+		{Emails: []string{"b@x.org", "a@x.org"}, TimeSlot: "PM", Line: 3},
+	}
+	loader, err := data.Load([]byte(groupRequests), []byte(groupEvents), data.Options{Groups: groups})
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	for _, strategy := range []Strategy{GreedyStrategy{}, OptimalStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			s, err := NewSchedulerWithData(loader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Workers, s.Seed = 1, 1
+			schedule := strategy.Schedule(s, 5)
+			type result struct {
+				TimeSlot string
+				Honored  bool
+				Apart    int
+			}
+			var got []result
+			for _, group := range s.groupResults(schedule.Students) {
+				got = append(got, result{group.TimeSlot, group.Honored, group.Apart})
+			}
+			want := []result{{"AM", false, 1}, {"PM", true, 0}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("group results = %+v, want %+v", got, want)
+			}
+			if unseated(schedule) != 0 || schedule.Score != 1+s.GroupWeight {
+				t.Errorf("scored %g with %d unseated slots, want a second choice and one student apart, %g", schedule.Score, unseated(schedule), 1+s.GroupWeight)
+			}
+		})
+	}
+}
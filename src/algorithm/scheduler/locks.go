@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// resolveLocks picks the section of every locked seat. Seats that don't name a section go to the section
+// of their course with the most seats left after the seats that do.
+func (s *Scheduler) resolveLocks() {
+	s.locked = make(map[string][]sectionKey)
+	s.lockedSections = make(map[sectionKey]int)
+	var open []data.Lock
+	for _, lock := range s.DataLoader.LockedSeats {
+		if lock.Section == 0 {
+			open = append(open, lock)
+			continue
+		}
+		s.lock(lock.Email, sectionKey{course: lock.Course, section: lock.Section})
+	}
+	for _, lock := range open {
+		var best *imp.Section
+		for _, section := range s.CourseNameToSections[lock.Course] {
+			room := section.MaxStudents - s.lockedSections[keyOf(section.Course)]
+			if room > 0 && (best == nil || room > best.MaxStudents-s.lockedSections[keyOf(best.Course)]) {
+				best = section
+			}
+		}
+		if best != nil {
+			s.lock(lock.Email, keyOf(best.Course))
+		}
+	}
+}
+
+func (s *Scheduler) lock(email string, key sectionKey) {
+	email = data.NormalizeEmail(email)
+	s.locked[email] = append(s.locked[email], key)
+	s.lockedSections[key]++
+}
+
+// lockedSectionsOf returns the sections the student is locked into
+func (s *Scheduler) lockedSectionsOf(student *imp.Student) []*imp.Section {
+	if len(s.locked) == 0 {
+		return nil
+	}
+	var sections []*imp.Section
+	for _, key := range s.locked[data.NormalizeEmail(student.StudentEmail)] {
+		for _, section := range s.CourseNameToSections[key.course] {
+			if section.Course.Section == key.section {
+				sections = append(sections, section)
+			}
+		}
+	}
+	return sections
+}
+
+// isLocked reports whether the student is locked into the section, which no strategy may move them out of
+func (s *Scheduler) isLocked(student *imp.Student, section *imp.Section) bool {
+	if s.lockedSections[keyOf(section.Course)] == 0 {
+		return false
+	}
+	for _, key := range s.locked[data.NormalizeEmail(student.StudentEmail)] {
+		if key == keyOf(section.Course) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedIntoCourse reports whether the student is locked into a section of the course
+func (s *Scheduler) lockedIntoCourse(student *imp.Student, course string) bool {
+	for _, key := range s.locked[data.NormalizeEmail(student.StudentEmail)] {
+		if key.course == course {
+			return true
+		}
+	}
+	return false
+}
+
+// seatLockedStudents seats every student in the sections they are locked into
+func (s *Scheduler) seatLockedStudents() {
+	if len(s.locked) == 0 {
+		return
+	}
+	for _, student := range s.DataLoader.Students {
+		for _, section := range s.lockedSectionsOf(student) {
+			s.safeAddStudentToSection(student, section)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+func TestLockedSeatsAreKeptByEveryStrategy(t *testing.T) {
+	// The juniors want the only seat of X, which the freshman is locked into without asking for it
+	requests := choiceHeaders +
+		"a@x.org,A,A,Junior,X,Y,,\n" +
+		"b@x.org,B,B,Junior,X,Y,,\n" +
+		"c@x.org,C,C,Freshman,Y,,,\n"
+	events := "Name,Max Students,Time Slot\nX,1,AM\nY,2,AM\n"
+	options := data.Options{Locks: []data.Lock{{Email: "C@x.org", Course: "X", Line: 2}}}
+	loader, err := data.Load([]byte(requests), []byte(events), options)
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	for _, strategy := range []Strategy{GreedyStrategy{}, OptimalStrategy{}, AnnealStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			s, err := NewSchedulerWithData(loader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Workers, s.Seed = 1, 1
+			s.Anneal = &AnnealOptions{StartTemperature: 4, EndTemperature: 0.05, Steps: 20000}
+			schedule := strategy.Schedule(s, 5)
+			for _, student := range schedule.Students {
+				want := "Y"
+				if student.StudentEmail == "c@x.org" {
+					want = "X"
+				}
+				if got := student.EnrolledCourses["AM"].CourseName; got != want {
+					t.Errorf("%s is in %q, want %q", student.StudentEmail, got, want)
+				}
+			}
+		})
+	}
+}
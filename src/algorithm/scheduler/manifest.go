@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// Manifest records everything needed to reproduce a run, and is written next to its results
+type Manifest struct {
+	Seed        int64             `json:"seed"`
+	Iterations  int               `json:"iterations"`
+	Strategy    string            `json:"strategy"`
+	Weights     *imp.RankWeights  `json:"weights"`
+	Anneal      *AnnealOptions    `json:"anneal,omitempty"`
+	AnnealSteps int               `json:"annealSteps,omitempty"`
+	Inputs      map[string]string `json:"inputs"`
+	Score       float64           `json:"score"`
+	// Duplicates is the policy the requests of students who submitted the form more than once were read with
+	Duplicates string `json:"duplicates,omitempty"`
+	// Workers is the number of trials the run ran at once
+	Workers             int            `json:"workers,omitempty"`
+	CancelUnderEnrolled bool           `json:"cancelUnderEnrolled"`
+	Cancelled           []Cancellation `json:"cancelled,omitempty"`
+	GroupWeight         *float64       `json:"groupWeight,omitempty"`
+	// Groups is only set when the run had group requests
+	Groups []GroupResult `json:"groups,omitempty"`
+}
+
+// ReadManifest loads a manifest written by an earlier run
+func ReadManifest(path string) (*Manifest, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(contents, manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// Apply configures the scheduler to repeat the recorded run and returns its number of iterations.
+// The recorded seed, strategy, weights and other options replace those of the scheduler. It fails if
+// the input files are not the ones the manifest was written for, if an input file the run didn't have
+// exists now, or if the data was read with another duplicates policy.
+func (m *Manifest) Apply(s *Scheduler) (int, error) {
+	for path, hash := range m.Inputs {
+		if s.DataLoader.Inputs[path] != hash {
+			return 0, fmt.Errorf("%s has changed since the manifest was written", path)
+		}
+	}
+	for path := range s.DataLoader.Inputs {
+		if _, ok := m.Inputs[path]; !ok {
+			return 0, fmt.Errorf("%s was not an input of the run the manifest was written for", path)
+		}
+	}
+	recorded, err := data.ParseDuplicatePolicy(m.Duplicates)
+	if err != nil {
+		return 0, err
+	}
+	if policy, err := data.ParseDuplicatePolicy(s.DataLoader.Options.Duplicates); err != nil || policy != recorded {
+		return 0, fmt.Errorf("the run the manifest was written for read duplicate requests with the %s policy", recorded)
+	}
+	strategy, err := LookupStrategy(m.Strategy)
+	if err != nil {
+		return 0, err
+	}
+
+	s.Seed = m.Seed
+	s.Strategy = strategy
+	s.Weights = m.Weights
+	if m.Workers > 0 {
+		s.Workers = m.Workers
+	}
+	s.CancelUnderEnrolled = m.CancelUnderEnrolled
+	if m.GroupWeight != nil {
+		s.GroupWeight = *m.GroupWeight
+	}
+	if m.Anneal != nil {
+		options := *m.Anneal
+		options.stopAfter = m.AnnealSteps
+		s.Anneal = &options
+	}
+	return m.Iterations, nil
+}
+
+func (s *Scheduler) newManifest(numIterations int, strategy Strategy, schedule *Schedule) (*Manifest, error) {
+	if len(s.DataLoader.Inputs) == 0 {
+		return nil, errors.New("the input files could not be read")
+	}
+	manifest := &Manifest{
+		Seed:                s.Seed,
+		Iterations:          numIterations,
+		Strategy:            strategy.Name(),
+		Weights:             schedule.Weights,
+		Inputs:              s.DataLoader.Inputs,
+		Score:               schedule.Score,
+		Workers:             s.Workers,
+		CancelUnderEnrolled: s.CancelUnderEnrolled,
+		Cancelled:           schedule.Cancelled,
+		GroupWeight:         &s.GroupWeight,
+		Groups:              schedule.Groups,
+	}
+	manifest.Duplicates, _ = data.ParseDuplicatePolicy(s.DataLoader.Options.Duplicates)
+	if schedule.Improved {
+		options := s.Anneal
+		if options == nil {
+			options = &DefaultAnnealOptions
+		}
+		manifest.Anneal = options
+		manifest.AnnealSteps = schedule.AnnealSteps
+	}
+	return manifest, nil
+}
+
+func writeManifest(path, runName string, manifest *Manifest) error {
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("%smanifest_%s.json", path, runName)
+	return os.WriteFile(filename, append(contents, '\n'), 0644)
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+func recordedRun(t *testing.T) *Manifest {
+	t.Helper()
+	s := newTestScheduler(t, crowdedRequests, crowdedEvents)
+	s.Seed = 7
+	s.Workers = 3
+	s.GroupWeight = 5
+	s.CancelUnderEnrolled = true
+	schedule := GreedyStrategy{}.Schedule(s, 4)
+	schedule.Weights = &imp.DefaultRankWeights
+	manifest, err := s.newManifest(4, GreedyStrategy{}, schedule)
+	if err != nil {
+		t.Fatalf("newManifest: %v", err)
+	}
+	return manifest
+}
+
+func TestManifestApplyRestoresTheRecordedOptions(t *testing.T) {
+	manifest := recordedRun(t)
+	if manifest.Duplicates != data.DuplicatesLatest {
+		t.Errorf("recorded the duplicates policy %q, want %q", manifest.Duplicates, data.DuplicatesLatest)
+	}
+
+	s := newTestScheduler(t, crowdedRequests, crowdedEvents)
+	iterations, err := manifest.Apply(s)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if iterations != 4 || s.Seed != 7 || s.Workers != 3 || s.GroupWeight != 5 || !s.CancelUnderEnrolled {
+		t.Errorf("Apply set %d iterations, seed %d, %d workers, group weight %g and cancel %v, want the recorded run's", iterations, s.Seed, s.Workers, s.GroupWeight, s.CancelUnderEnrolled)
+	}
+}
+
+func TestManifestApplyRejectsADifferentRun(t *testing.T) {
+	columns := data.DefaultColumnMapping
+	columns.AM, columns.PM = columns.AM[:2], columns.PM[:2]
+	tests := []struct {
+		name    string
+		options data.Options
+		want    string
+	}{
+		{"input the run didn't have", data.Options{Columns: &columns}, "was not an input"},
+		{"other duplicates policy", data.Options{Duplicates: data.DuplicatesFirst}, "policy"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := recordedRun(t)
+			loader, err := data.Load([]byte(crowdedRequests), []byte(crowdedEvents), test.options)
+			if err != nil {
+				t.Fatalf("loading the data: %v", err)
+			}
+			s, err := NewSchedulerWithData(loader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := manifest.Apply(s); err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Apply() = %v, want an error about the %s", err, test.name)
+			}
+		})
+	}
+}
+
+func TestManifestApplyRejectsChangedInputs(t *testing.T) {
+	manifest := recordedRun(t)
+	s := newTestScheduler(t, crowdedRequests+"g@x.org,G,G,Junior,Y,,\n", crowdedEvents)
+	if _, err := manifest.Apply(s); err == nil || !strings.Contains(err.Error(), data.RequestsFile) {
+		t.Errorf("Apply() = %v, want an error about the changed %s", err, data.RequestsFile)
+	}
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"container/heap"
+	"math"
+)
+
+const infiniteCapacity = math.MaxInt32
+
+type flowEdge struct {
+	to       int
+	rev      int
+	capacity int
+	cost     int64
+}
+
+// flowRef identifies an edge of a flowGraph so its flow can be read back after solving
+type flowRef struct {
+	from  int
+	index int
+}
+
+// flowGraph is a min-cost max-flow network solved with successive shortest paths
+type flowGraph struct {
+	edges [][]flowEdge
+}
+
+func newFlowGraph(nodes int) *flowGraph {
+	return &flowGraph{edges: make([][]flowEdge, nodes)}
+}
+
+func (g *flowGraph) addNode() int {
+	g.edges = append(g.edges, nil)
+	return len(g.edges) - 1
+}
+
+func (g *flowGraph) addEdge(from, to, capacity int, cost int64) flowRef {
+	ref := flowRef{from: from, index: len(g.edges[from])}
+	g.edges[from] = append(g.edges[from], flowEdge{to: to, rev: len(g.edges[to]), capacity: capacity, cost: cost})
+	g.edges[to] = append(g.edges[to], flowEdge{to: from, rev: ref.index, capacity: 0, cost: -cost})
+	return ref
+}
+
+// flow returns the amount of flow pushed through the edge
+func (g *flowGraph) flow(ref flowRef) int {
+	edge := g.edges[ref.from][ref.index]
+	return g.edges[edge.to][edge.rev].capacity
+}
+
+// minCostMaxFlow pushes as much flow as possible from source to sink and, among all
+// maximum flows, finds the one with the lowest total cost. Edge costs must be non-negative.
+func (g *flowGraph) minCostMaxFlow(source, sink int) (int, int64) {
+	nodes := len(g.edges)
+	potential := make([]int64, nodes)
+	dist := make([]int64, nodes)
+
+	totalFlow, totalCost := 0, int64(0)
+	for {
+		// Dijkstra on reduced costs, which stay non-negative thanks to the potentials
+		for i := range dist {
+			dist[i] = math.MaxInt64
+		}
+		dist[source] = 0
+		queue := &distanceQueue{{node: source}}
+		for queue.Len() > 0 {
+			item := heap.Pop(queue).(distanceItem)
+			if item.dist > dist[item.node] {
+				continue
+			}
+			for _, edge := range g.edges[item.node] {
+				if edge.capacity == 0 {
+					continue
+				}
+				reduced := item.dist + edge.cost + potential[item.node] - potential[edge.to]
+				if reduced < dist[edge.to] {
+					dist[edge.to] = reduced
+					heap.Push(queue, distanceItem{node: edge.to, dist: reduced})
+				}
+			}
+		}
+		if dist[sink] == math.MaxInt64 {
+			return totalFlow, totalCost
+		}
+		for i := range potential {
+			if dist[i] < dist[sink] {
+				potential[i] += dist[i]
+			} else {
+				potential[i] += dist[sink]
+			}
+		}
+
+		// Push flow along zero reduced cost paths until none is left, since they are all shortest paths
+		next := make([]int, nodes)
+		visited := make([]bool, nodes)
+		for {
+			for i := range visited {
+				visited[i] = false
+			}
+			pushed, cost := g.augment(source, sink, infiniteCapacity, potential, next, visited)
+			if pushed == 0 {
+				break
+			}
+			totalFlow += pushed
+			totalCost += cost
+		}
+	}
+}
+
+// augment pushes flow along one path of zero reduced cost edges and returns the amount and its cost
+func (g *flowGraph) augment(node, sink, limit int, potential []int64, next []int, visited []bool) (int, int64) {
+	if node == sink {
+		return limit, 0
+	}
+	visited[node] = true
+	for ; next[node] < len(g.edges[node]); next[node]++ {
+		edge := &g.edges[node][next[node]]
+		if edge.capacity == 0 || visited[edge.to] || edge.cost+potential[node]-potential[edge.to] != 0 {
+			continue
+		}
+		push := limit
+		if edge.capacity < push {
+			push = edge.capacity
+		}
+		if pushed, cost := g.augment(edge.to, sink, push, potential, next, visited); pushed > 0 {
+			edge.capacity -= pushed
+			g.edges[edge.to][edge.rev].capacity += pushed
+			return pushed, cost + int64(pushed)*edge.cost
+		}
+	}
+	return 0, 0
+}
+
+type distanceItem struct {
+	node int
+	dist int64
+}
+
+type distanceQueue []distanceItem
+
+func (q distanceQueue) Len() int            { return len(q) }
+func (q distanceQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q distanceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x interface{}) { *q = append(*q, x.(distanceItem)) }
+func (q *distanceQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
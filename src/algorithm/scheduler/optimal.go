@@ -0,0 +1,668 @@
+package scheduler
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+// flowCostScale converts fractional satisfaction scores into the integer costs used by the flow network
+const flowCostScale = 1000
+
+// unseatedCost is the flow cost of leaving a time slot of a student unseated. It dwarfs any
+// satisfaction cost so that seating as many students as possible always comes first.
+const unseatedCost = 1 << 40
+
+// OptimalStrategy seats the students with a min-cost max-flow solver instead of shuffling.
+// The iteration count bounds the number of branch and bound nodes it may explore.
+type OptimalStrategy struct{}
+
+func init() {
+	RegisterStrategy(OptimalStrategy{})
+}
+
+func (OptimalStrategy) Name() string {
+	return "optimal"
+}
+
+func (OptimalStrategy) Schedule(s *Scheduler, numIterations int) *Schedule {
+	for _, student := range s.DataLoader.Students {
+		student.UnrollEverything()
+	}
+	optimal := s.AssignStudentsOptimally(numIterations)
+	// The flow has no notion of group requests or balance rules, they are only honored afterwards where
+	// that pays off, so the schedule is no longer proven optimal once there are any
+	s.gatherGroups()
+	schedule := s.snapshot()
+	schedule.Optimal = optimal && len(s.groups) == 0 && len(s.balanceRules()) == 0
+	s.ClearSections()
+	return schedule
+}
+
+// slotPlan holds the section of every student in every time slot, by student and then by the position of
+// the slot, nil when the slot is unseated. A section of several slots is held in each of them.
+type slotPlan struct {
+	sections [][]*imp.Section
+	// positions maps every time slot to its position
+	positions map[string]int
+	seats     int
+	cost      int64
+}
+
+// newSlotPlan returns a plan without seats for the students and time slots of the scheduler
+func (s *Scheduler) newSlotPlan() *slotPlan {
+	plan := &slotPlan{
+		sections:  make([][]*imp.Section, len(s.DataLoader.Students)),
+		positions: make(map[string]int),
+	}
+	for k, timeSlot := range s.timeSlots() {
+		plan.positions[timeSlot] = k
+	}
+	for i := range plan.sections {
+		plan.sections[i] = make([]*imp.Section, len(plan.positions))
+	}
+	return plan
+}
+
+func (p *slotPlan) copy() *slotPlan {
+	copied := &slotPlan{sections: make([][]*imp.Section, len(p.sections)), positions: p.positions}
+	for i, held := range p.sections {
+		copied.sections[i] = append([]*imp.Section(nil), held...)
+	}
+	return copied
+}
+
+// better reports whether p seats more students than other, or as many at a lower cost
+func (p *slotPlan) better(other *slotPlan) bool {
+	if other == nil {
+		return true
+	}
+	if p.seats != other.seats {
+		return p.seats > other.seats
+	}
+	return p.cost < other.cost
+}
+
+// indexes returns the positions of the time slots the section takes up
+func (p *slotPlan) indexes(section *imp.Section) []int {
+	indexes := make([]int, 0, len(section.Course.Slots))
+	for _, timeSlot := range section.Course.Slots {
+		if k, ok := p.positions[timeSlot]; ok {
+			indexes = append(indexes, k)
+		}
+	}
+	return indexes
+}
+
+// hold seats the student in the section in every time slot it takes up
+func (p *slotPlan) hold(student int, section *imp.Section) {
+	for _, k := range p.indexes(section) {
+		p.sections[student][k] = section
+	}
+}
+
+// release takes the student out of the section in every time slot they hold it in
+func (p *slotPlan) release(student int, section *imp.Section) {
+	for k, held := range p.sections[student] {
+		if held == section {
+			p.sections[student][k] = nil
+		}
+	}
+}
+
+// whole reports whether the student holds the section in every time slot it takes up
+func (p *slotPlan) whole(student int, section *imp.Section) bool {
+	for _, k := range p.indexes(section) {
+		if p.sections[student][k] != section {
+			return false
+		}
+	}
+	return true
+}
+
+// held returns the sections the student holds, each once, in the order of their first time slot
+func (p *slotPlan) held(student int) []*imp.Section {
+	var sections []*imp.Section
+	for k, section := range p.sections[student] {
+		if section != nil && (k == 0 || !containsSection(p.sections[student][:k], section)) {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+func containsSection(sections []*imp.Section, section *imp.Section) bool {
+	for _, other := range sections {
+		if other == section {
+			return true
+		}
+	}
+	return false
+}
+
+// multiSlotChoice is a student's seat in a section of several time slots
+type multiSlotChoice struct {
+	student int
+	section *imp.Section
+}
+
+// courseExclusion keeps a student out of every section of a course
+type courseExclusion struct {
+	student int
+	course  string
+}
+
+// flowConstraints are the branching decisions of the branch and bound search
+type flowConstraints struct {
+	seated    map[multiSlotChoice]bool
+	forbidden map[multiSlotChoice]bool
+	excluded  map[courseExclusion]bool
+}
+
+func (c flowConstraints) with(student int, section *imp.Section, seat bool) flowConstraints {
+	next := c.copy()
+	if seat {
+		next.seated[multiSlotChoice{student: student, section: section}] = true
+	} else {
+		next.forbidden[multiSlotChoice{student: student, section: section}] = true
+	}
+	return next
+}
+
+// without keeps the student out of the course, to part them from someone they must be kept apart from
+func (c flowConstraints) without(student int, course string) flowConstraints {
+	next := c.copy()
+	next.excluded[courseExclusion{student: student, course: course}] = true
+	return next
+}
+
+func (c flowConstraints) copy() flowConstraints {
+	next := flowConstraints{
+		seated:    make(map[multiSlotChoice]bool, len(c.seated)+1),
+		forbidden: make(map[multiSlotChoice]bool, len(c.forbidden)+1),
+		excluded:  make(map[courseExclusion]bool, len(c.excluded)+1),
+	}
+	for k, v := range c.seated {
+		next.seated[k] = v
+	}
+	for k, v := range c.forbidden {
+		next.forbidden[k] = v
+	}
+	for k, v := range c.excluded {
+		next.excluded[k] = v
+	}
+	return next
+}
+
+// AssignStudentsOptimally is an alternative to AssignStudentsToSections that finds the schedule
+// seating the most students with the lowest total satisfaction score. Every student is modeled
+// as a seat in each time slot of a min-cost flow network; courses of several slots that end up
+// held in only some of them, and students who must be kept apart but share a course, are resolved by
+// branching on them. It returns true when the result is
+// proven optimal, and false when maxNodes ran out first and the best schedule found was used.
+func (s *Scheduler) AssignStudentsOptimally(maxNodes int) bool {
+	var best *slotPlan
+	open := &planQueue{}
+	nodes := 0
+	mates := s.apartIndexes(s.DataLoader.Students)
+	solve := func(constraints flowConstraints) {
+		nodes++
+		s.tick()
+		if plan := s.solveSlots(constraints); plan != nil && plan.better(best) {
+			heap.Push(open, planNode{constraints: constraints, plan: plan})
+		}
+	}
+
+	// Best first: the relaxed plan with the lowest cost is a bound on everything still open
+	solve(flowConstraints{seated: make(map[multiSlotChoice]bool), forbidden: make(map[multiSlotChoice]bool), excluded: make(map[courseExclusion]bool)})
+	for open.Len() > 0 && (nodes < maxNodes || best == nil) && !s.stopped() {
+		node := heap.Pop(open).(planNode)
+		if !node.plan.better(best) {
+			open = &planQueue{}
+			break
+		}
+		student, section := node.plan.splitSection()
+		first, second, course := node.plan.sharedCourse(mates)
+		if student < 0 && first < 0 {
+			best = node.plan
+			s.reportScore(best.score())
+			continue
+		}
+		if repaired := s.repairSlots(node.plan, mates); repaired.better(best) {
+			best = repaired
+			s.reportScore(best.score())
+		}
+		if student >= 0 {
+			solve(node.constraints.with(student, section, true))
+			solve(node.constraints.with(student, section, false))
+		} else {
+			// Only the student who isn't locked into the course can be moved out of it
+			for _, i := range []int{first, second} {
+				if !s.lockedIntoCourse(s.DataLoader.Students[i], course) {
+					solve(node.constraints.without(i, course))
+				}
+			}
+		}
+	}
+	if best == nil {
+		return false
+	}
+	proven := open.Len() == 0 || !(*open)[0].plan.better(best)
+
+	// Sections of the same course cost the same, so the students of a course are spread over its sections
+	// once the locked seats are taken
+	s.seatLockedStudents()
+	seat := func(student *imp.Student, planned *imp.Section) {
+		if section := s.openSection(student, s.CourseNameToSections[planned.Course.CourseName]); section != nil {
+			s.safeAddStudentToSection(student, section)
+		}
+	}
+	for i, student := range s.DataLoader.Students {
+		for _, section := range best.held(i) {
+			if !s.isLocked(student, section) {
+				seat(student, section)
+			}
+		}
+	}
+	// Hard balance rules can leave no section of a planned course open to a student, who then gets
+	// a seat the way the greedy strategy would find one
+	if s.hasHardBalance() {
+		s.fillOpenSlots()
+	}
+	return proven
+}
+
+type planNode struct {
+	constraints flowConstraints
+	plan        *slotPlan
+}
+
+type planQueue []planNode
+
+func (q planQueue) Len() int            { return len(q) }
+func (q planQueue) Less(i, j int) bool  { return q[i].plan.better(q[j].plan) }
+func (q planQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *planQueue) Push(x interface{}) { *q = append(*q, x.(planNode)) }
+func (q *planQueue) Pop() interface{} {
+	old := *q
+	node := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return node
+}
+
+// solveSlots solves a flow relaxation of the problem and returns nil when the constraints can't be met.
+// Every student sends one unit of flow from a seat in each time slot into a course of the slot. A course
+// that takes up several slots has a node in each of them and is chosen in each at a share of its cost.
+// The relaxation is that a student may hold it in only some of its slots.
+func (s *Scheduler) solveSlots(constraints flowConstraints) *slotPlan {
+	students := s.DataLoader.Students
+	sections := s.sortedSections()
+	timeSlots := s.timeSlots()
+	plan := s.newSlotPlan()
+
+	remaining := make(map[*imp.Section]int, len(sections))
+	for _, section := range sections {
+		remaining[section] = section.MaxStudents - len(section.Students)
+	}
+	for choice := range constraints.seated {
+		remaining[choice.section]--
+		if remaining[choice.section] < 0 {
+			return nil
+		}
+		plan.hold(choice.student, choice.section)
+	}
+	// Locked seats are taken out of the flow, a student locked into some slots only flows through the others
+	for i, student := range students {
+		for _, section := range s.lockedSectionsOf(student) {
+			remaining[section]--
+			plan.hold(i, section)
+		}
+	}
+
+	const source, sink = 0, 1
+	g := newFlowGraph(2)
+	type slotNode struct {
+		section *imp.Section
+		slot    int
+	}
+	nodes := make(map[slotNode]int)
+	for _, section := range sections {
+		for _, k := range plan.indexes(section) {
+			nodes[slotNode{section, k}] = g.addNode()
+			g.addEdge(nodes[slotNode{section, k}], sink, remaining[section], 0)
+		}
+	}
+
+	excluded := make(map[int]map[string]bool)
+	for exclusion := range constraints.excluded {
+		if excluded[exclusion.student] == nil {
+			excluded[exclusion.student] = make(map[string]bool)
+		}
+		excluded[exclusion.student][exclusion.course] = true
+	}
+
+	// Students of a grade share a hub per time slot that leads to every section of the slot their grade may
+	// take, apart from the courses they are kept out of
+	var hubs []*sectionHub
+	hubsByKey := make(map[string]*sectionHub)
+	hubFor := func(k int, grade string, without map[string]bool) *sectionHub {
+		key := timeSlots[k] + "/" + strings.ToLower(strings.TrimSpace(grade))
+		if len(without) > 0 {
+			courses := make([]string, 0, len(without))
+			for course := range without {
+				courses = append(courses, course)
+			}
+			sort.Strings(courses)
+			key += "/" + strings.Join(courses, "\x00")
+		}
+		if hub, ok := hubsByKey[key]; ok {
+			return hub
+		}
+		hub := &sectionHub{node: g.addNode(), slot: k}
+		for _, section := range sections {
+			if section.Course.TimeSlot != timeSlots[k] || !section.Course.IsEligible(grade) || without[section.Course.CourseName] {
+				continue
+			}
+			hub.sections = append(hub.sections, section)
+			hub.refs = append(hub.refs, g.addEdge(hub.node, nodes[slotNode{section, k}], infiniteCapacity, 0))
+		}
+		hubsByKey[key] = hub
+		hubs = append(hubs, hub)
+		return hub
+	}
+
+	type seatEdge struct {
+		student int
+		slot    int
+		section *imp.Section // nil for the edge through a hub of unrequested sections
+		hub     *sectionHub
+		ref     flowRef
+	}
+	var seatEdges []seatEdge
+	for i, student := range students {
+		seats := make([]int, len(timeSlots))
+		for k := range timeSlots {
+			seats[k] = -1
+			if plan.sections[i][k] == nil {
+				seats[k] = g.addNode()
+				g.addEdge(source, seats[k], 1, 0)
+				g.addEdge(seats[k], sink, 1, unseatedCost)
+			}
+		}
+		open := func(section *imp.Section) bool {
+			for _, k := range plan.indexes(section) {
+				if seats[k] < 0 {
+					return false
+				}
+			}
+			return true
+		}
+
+		requested := make(map[*imp.Section]bool)
+		// A request for a course can be met by any of its sections, and a course that takes up several time
+		// slots is requested among the choices of its first one
+		for _, timeSlot := range timeSlots {
+			for _, courseName := range student.RequestedCourses.GetCourses(timeSlot) {
+				for _, section := range s.CourseNameToSections[courseName] {
+					if requested[section] || section.Course.Slots[0] != timeSlot || !section.Course.IsEligible(student.Grade) || excluded[i][courseName] {
+						continue
+					}
+					requested[section] = true
+					if !open(section) || constraints.forbidden[multiSlotChoice{student: i, section: section}] {
+						continue
+					}
+					cost := sectionCost(student, section)
+					if section.Course.MultiSlot() {
+						cost = splitSectionCost(student, section)
+					}
+					for _, k := range plan.indexes(section) {
+						seatEdges = append(seatEdges, seatEdge{student: i, slot: k, section: section, ref: g.addEdge(seats[k], nodes[slotNode{section, k}], 1, cost)})
+					}
+				}
+			}
+		}
+
+		// Every unrequested section of a time slot costs the same, so they share one hub
+		for k, timeSlot := range timeSlots {
+			if seats[k] < 0 {
+				continue
+			}
+			for _, section := range sections {
+				if !requested[section] && section.Course.TimeSlot == timeSlot && section.Course.IsEligible(student.Grade) && !excluded[i][section.Course.CourseName] {
+					hub := hubFor(k, student.Grade, excluded[i])
+					seatEdges = append(seatEdges, seatEdge{student: i, slot: k, hub: hub, ref: g.addEdge(seats[k], hub.node, 1, sectionCost(student, section))})
+					break
+				}
+			}
+		}
+	}
+
+	g.minCostMaxFlow(source, sink)
+
+	for _, edge := range seatEdges {
+		if g.flow(edge.ref) == 0 {
+			continue
+		}
+		if edge.hub != nil {
+			edge.hub.students = append(edge.hub.students, edge.student)
+		} else {
+			plan.sections[edge.student][edge.slot] = edge.section
+		}
+	}
+	for _, hub := range hubs {
+		for j, section := range hub.sections {
+			for n := g.flow(hub.refs[j]); n > 0; n-- {
+				plan.sections[hub.students[0]][hub.slot] = section
+				hub.students = hub.students[1:]
+			}
+		}
+	}
+
+	s.scoreSlots(plan)
+	return plan
+}
+
+// sectionHub gathers the unrequested sections of a time slot that a grade may take, the students routed
+// through it are spread over the sections by the flow each section receives
+type sectionHub struct {
+	node     int
+	slot     int
+	sections []*imp.Section
+	refs     []flowRef
+	students []int
+}
+
+// splitSection returns a student who holds a section of several time slots in only some of them, or -1
+// if there is none
+func (p *slotPlan) splitSection() (int, *imp.Section) {
+	for i, held := range p.sections {
+		for _, section := range held {
+			if section != nil && section.Course.MultiSlot() && !p.whole(i, section) {
+				return i, section
+			}
+		}
+	}
+	return -1, nil
+}
+
+// sharedCourse returns two students who must be kept apart but share a course in the plan, and the
+// course, or -1 when there are none
+func (p *slotPlan) sharedCourse(mates [][]int) (int, int, string) {
+	for i := range mates {
+		for _, j := range mates[i] {
+			for _, mine := range p.sections[i] {
+				for _, theirs := range p.sections[j] {
+					if mine != nil && theirs != nil && mine.Course.CourseName == theirs.Course.CourseName {
+						return i, j, mine.Course.CourseName
+					}
+				}
+			}
+		}
+	}
+	return -1, -1, ""
+}
+
+// repairSlots turns a relaxed plan into a valid one by dropping the seats of students in a course with
+// someone they must be kept apart from, giving sections held in only some of their time slots the rest of
+// them while they have room and dropping them otherwise, and filling the freed slots with the cheapest
+// sections that still have room.
+func (s *Scheduler) repairSlots(relaxed *slotPlan, mates [][]int) *slotPlan {
+	students := s.DataLoader.Students
+	sections := s.sortedSections()
+	timeSlots := s.timeSlots()
+	plan := relaxed.copy()
+
+	remaining := make(map[*imp.Section]int, len(sections))
+	for _, section := range sections {
+		remaining[section] = section.MaxStudents - len(section.Students)
+	}
+	// Locked seats are taken first, so a student kept apart from a locked student is the one who moves
+	members := make(map[string][]int)
+	sharesWithMate := func(i int, course string) bool {
+		if mates == nil {
+			return false
+		}
+		for _, j := range mates[i] {
+			for _, k := range members[course] {
+				if k == j {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for i, student := range students {
+		for _, section := range s.lockedSectionsOf(student) {
+			members[section.Course.CourseName] = append(members[section.Course.CourseName], i)
+		}
+	}
+	split := func(i int, section *imp.Section) bool {
+		return section.Course.MultiSlot() && !plan.whole(i, section)
+	}
+	for i, student := range students {
+		for _, section := range plan.held(i) {
+			if split(i, section) || s.isLocked(student, section) {
+				continue
+			}
+			if sharesWithMate(i, section.Course.CourseName) {
+				plan.release(i, section)
+			} else if mates != nil {
+				members[section.Course.CourseName] = append(members[section.Course.CourseName], i)
+			}
+		}
+		for _, section := range plan.held(i) {
+			if !split(i, section) {
+				remaining[section]--
+			}
+		}
+	}
+	// A section held in only some of its time slots takes up the rest of them while it has room
+	for i, student := range students {
+		for _, section := range plan.held(i) {
+			if !containsSection(plan.sections[i], section) || !split(i, section) {
+				continue
+			}
+			plan.release(i, section)
+			if remaining[section] <= 0 || sharesWithMate(i, section.Course.CourseName) || !s.releasable(plan, i, student, section) {
+				continue
+			}
+			for _, other := range plan.held(i) {
+				if overlaps(other, section) {
+					if !split(i, other) {
+						remaining[other]++
+					}
+					plan.release(i, other)
+				}
+			}
+			plan.hold(i, section)
+			remaining[section]--
+			if mates != nil {
+				members[section.Course.CourseName] = append(members[section.Course.CourseName], i)
+			}
+		}
+	}
+
+	cheapest := func(i int, student *imp.Student, timeSlot string) *imp.Section {
+		var found *imp.Section
+		for _, section := range sections {
+			if section.Course.TimeSlot != timeSlot || remaining[section] <= 0 || !section.Course.IsEligible(student.Grade) || sharesWithMate(i, section.Course.CourseName) {
+				continue
+			}
+			if found == nil || sectionCost(student, section) < sectionCost(student, found) {
+				found = section
+			}
+		}
+		if found != nil {
+			remaining[found]--
+			if mates != nil {
+				members[found.Course.CourseName] = append(members[found.Course.CourseName], i)
+			}
+		}
+		return found
+	}
+	for i, student := range students {
+		for k, timeSlot := range timeSlots {
+			if plan.sections[i][k] == nil {
+				plan.sections[i][k] = cheapest(i, student, timeSlot)
+			}
+		}
+	}
+
+	s.scoreSlots(plan)
+	return plan
+}
+
+// scoreSlots fills in the number of seated time slots and the total cost of a plan
+func (s *Scheduler) scoreSlots(plan *slotPlan) {
+	plan.seats, plan.cost = 0, 0
+	for i, student := range s.DataLoader.Students {
+		for _, section := range plan.sections[i] {
+			if section == nil {
+				continue
+			}
+			plan.seats++
+			if section.Course.MultiSlot() {
+				plan.cost += splitSectionCost(student, section)
+			} else {
+				plan.cost += sectionCost(student, section)
+			}
+		}
+	}
+}
+
+// score returns the satisfaction score of the plan, in the units of Schedule.Score
+func (p *slotPlan) score() float64 {
+	return float64(p.cost) / flowCostScale
+}
+
+func sectionCost(student *imp.Student, section *imp.Section) int64 {
+	return int64(math.Round(student.CourseCost(section.Course) * flowCostScale))
+}
+
+// splitSectionCost is the share of the cost of a section of several time slots in each of them
+func splitSectionCost(student *imp.Student, section *imp.Section) int64 {
+	return int64(math.Round(student.CourseCost(section.Course) * flowCostScale / float64(len(section.Course.Slots))))
+}
+
+// releasable reports whether the student holds no locked section in the time slots of the section
+func (s *Scheduler) releasable(plan *slotPlan, i int, student *imp.Student, section *imp.Section) bool {
+	for _, other := range plan.held(i) {
+		if overlaps(other, section) && s.isLocked(student, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether the sections share a time slot
+func overlaps(section, other *imp.Section) bool {
+	for _, timeSlot := range section.Course.Slots {
+		if other.Course.Occupies(timeSlot) {
+			return true
+		}
+	}
+	return false
+}
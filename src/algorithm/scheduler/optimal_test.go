@@ -0,0 +1,74 @@
+package scheduler
+
+import "testing"
+
+// Each fixture has a known best score under the default weights, which the optimal strategy must prove
+// and the greedy and annealing strategies can only reach or exceed
+var solverFixtures = []struct {
+	name     string
+	requests string
+	events   string
+	optimum  float64
+}{
+	{
+		name: "everyone gets their first choice",
+		requests: choiceHeaders +
+			"a@x.org,A,A,Junior,X,,P,\n" +
+			"b@x.org,B,B,Sophomore,Y,,P,\n",
+		events:  "Name,Max Students,Time Slot\nX,1,AM\nY,1,AM\nP,2,PM\n",
+		optimum: 0,
+	},
+	{
+		// Two students want X first, one of them has to take their third choice so that C keeps Y
+		name: "contested first choice",
+		requests: "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+			"AM Course - 1st Choice. (Drop down option),AM Course - 2nd Choice. (Drop down option),AM Course - 3rd Choice. (Drop down option)," +
+			"PM Course - 1st Choice. (Drop down option)\n" +
+			"a@x.org,A,A,Junior,X,Y,Z,P\n" +
+			"b@x.org,B,B,Junior,X,Y,Z,P\n" +
+			"c@x.org,C,C,Junior,Y,X,Z,P\n",
+		events:  "Name,Max Students,Time Slot\nX,1,AM\nY,1,AM\nZ,1,AM\nP,3,PM\n",
+		optimum: 2,
+	},
+	{
+		name:     "freshman seated behind a junior",
+		requests: crowdedRequests,
+		events:   crowdedEvents,
+		optimum:  1,
+	},
+	{
+		// A takes the full day course in both slots, B settles for their second choices
+		name: "full day course",
+		requests: choiceHeaders +
+			"a@x.org,A,A,Junior,F,X,F,P\n" +
+			"b@x.org,B,B,Sophomore,F,X,F,P\n",
+		events:  "Name,Max Students,Time Slot\nF,1,FullDay\nX,1,AM\nP,1,PM\n",
+		optimum: 2,
+	},
+}
+
+func TestSolversAgainstKnownOptimum(t *testing.T) {
+	for _, fixture := range solverFixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			optimal := OptimalStrategy{}.Schedule(newTestScheduler(t, fixture.requests, fixture.events), 10)
+			if optimal.Score != fixture.optimum || !optimal.Optimal {
+				t.Errorf("optimal scored %g, proven %v, want %g proven", optimal.Score, optimal.Optimal, fixture.optimum)
+			}
+
+			greedy := GreedyStrategy{}.Schedule(newTestScheduler(t, fixture.requests, fixture.events), 20)
+			if greedy.Score < fixture.optimum {
+				t.Errorf("greedy scored %g, below the optimum %g", greedy.Score, fixture.optimum)
+			}
+
+			s := newTestScheduler(t, fixture.requests, fixture.events)
+			s.Anneal = &AnnealOptions{StartTemperature: 4, EndTemperature: 0.05, Steps: 5000}
+			annealed := AnnealStrategy{}.Schedule(s, 20)
+			if annealed.Score < fixture.optimum || annealed.Score > annealed.GreedyScore {
+				t.Errorf("annealing scored %g from a greedy fill of %g, want between the optimum %g and the fill", annealed.Score, annealed.GreedyScore, fixture.optimum)
+			}
+			if annealed.Score != fixture.optimum {
+				t.Errorf("annealing scored %g, want the optimum %g on so small a fixture", annealed.Score, fixture.optimum)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import "sync"
+
+// Progress follows a run from another goroutine, such as a job status request.
+// It is safe for concurrent use.
+type Progress struct {
+	mu        sync.Mutex
+	done      int
+	total     int
+	bestScore float64
+	hasBest   bool
+}
+
+// Iterations returns the number of iterations done and the total number of iterations of the run
+func (p *Progress) Iterations() (int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done, p.total
+}
+
+// BestScore returns the lowest score found so far, and false if no schedule was found yet
+func (p *Progress) BestScore() (float64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.bestScore, p.hasBest
+}
+
+func (p *Progress) start(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done, p.total, p.hasBest = 0, total, false
+}
+
+func (p *Progress) step() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+}
+
+func (p *Progress) score(score float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.hasBest || score < p.bestScore {
+		p.bestScore, p.hasBest = score, true
+	}
+}
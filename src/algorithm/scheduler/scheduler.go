@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
@@ -8,36 +9,152 @@ import (
 	"github.com/schollz/progressbar/v3"
 	"math/rand"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Schedule struct {
-	Students []*imp.Student
-	Sections []*imp.Section
-	Score    float64
+	Students    []*imp.Student
+	Sections    []*imp.Section
+	Score       float64
+	Optimal     bool
+	Trial       int
+	Weights     *imp.RankWeights
+	GreedyScore float64
+	Improved    bool
+	AnnealSteps int
+	Cancelled   []Cancellation
+	Groups      []GroupResult
 }
 
 type Scheduler struct {
-	DataLoader          *data.DataLoader
-	CourseNameToSection map[string]*imp.Section
-	BestSchedule        *Schedule
+	DataLoader *data.DataLoader
+	// CourseNameToSections maps every course name to its sections, ordered by section number
+	CourseNameToSections map[string][]*imp.Section
+	BestSchedule         *Schedule
+	Strategy             Strategy
+	Weights              *imp.RankWeights
+	Anneal               *AnnealOptions
+	Workers              int
+	Seed                 int64
+	Progress             *Progress
+	// CancelUnderEnrolled cancels the sections that don't reach their minimum enrollment and places their students elsewhere
+	CancelUnderEnrolled bool
+	// GroupWeight is what every student placed apart from the rest of their group adds to the score
+	GroupWeight    float64
+	ctx            context.Context
+	bar            *progressbar.ProgressBar
+	rng            *rand.Rand
+	trial          int
+	loadedStudents []*imp.Student
+	sectionOrder   []*imp.Section
+	// locked maps the normalized email of every student with locked seats to their sections,
+	// and lockedSections counts the locked seats of every section
+	locked         map[string][]sectionKey
+	lockedSections map[sectionKey]int
+	// groups holds the group requests for each time slot, and groupsOf indexes them by normalized email
+	groups   []togetherGroup
+	groupsOf map[string][]int
+	// apart maps the normalized email of every student with keep-apart constraints to the students they
+	// must not share a course with, and infeasible explains why the last trial broke one
+	apart      map[string][]apartMate
+	infeasible error
 }
 
-func NewScheduler() *Scheduler {
+func NewScheduler() (*Scheduler, error) {
+	return NewSchedulerWithData(data.NewDataLoader())
+}
+
+// NewSchedulerWithData returns a scheduler for input data that was already loaded. It fails when a
+// course of the data has no row in the events file.
+func NewSchedulerWithData(loader *data.DataLoader) (*Scheduler, error) {
 	scheduler := &Scheduler{
-		DataLoader:          data.NewDataLoader(),
-		CourseNameToSection: make(map[string]*imp.Section),
+		DataLoader:           loader,
+		CourseNameToSections: make(map[string][]*imp.Section),
+		Seed:                 time.Now().UnixNano(),
+		GroupWeight:          DefaultGroupWeight,
+	}
+	scheduler.loadedStudents = append([]*imp.Student(nil), scheduler.DataLoader.Students...)
+	if err := scheduler.loadSections(); err != nil {
+		return nil, err
 	}
-	scheduler.loadSections()
-	return scheduler
+	scheduler.resolveLocks()
+	scheduler.resolveGroups()
+	scheduler.resolveKeepApart()
+	return scheduler, nil
+}
+
+// Clone returns a scheduler with the same configuration and input data as s, but with its own students,
+// sections and best schedule, so that it can run concurrently with s and with other clones. The loaded
+// data of s is only read, so s can serve as a template that is loaded once.
+func (s *Scheduler) Clone() *Scheduler {
+	clone := s.clone()
+	clone.Progress = nil
+	clone.ctx = nil
+	clone.bar = nil
+	return clone
 }
 
-func (s *Scheduler) loadSections() {
+// clone returns a scheduler with its own copies of the students and sections, so that
+// trials can run on it concurrently with the original
+func (s *Scheduler) clone() *Scheduler {
+	students := make([]*imp.Student, len(s.loadedStudents))
+	for i, student := range s.loadedStudents {
+		students[i] = student.DeepCopy()
+		students[i].UnrollEverything()
+	}
+	sections := make(map[string][]*imp.Section, len(s.CourseNameToSections))
+	for courseName, courseSections := range s.CourseNameToSections {
+		for _, section := range courseSections {
+			sections[courseName] = append(sections[courseName], section.EmptyCopy())
+		}
+	}
+	loader := *s.DataLoader
+	loader.Students = append([]*imp.Student(nil), students...)
+	return &Scheduler{
+		DataLoader:           &loader,
+		CourseNameToSections: sections,
+		Strategy:             s.Strategy,
+		Weights:              s.Weights,
+		Anneal:               s.Anneal,
+		Workers:              s.Workers,
+		Seed:                 s.Seed,
+		CancelUnderEnrolled:  s.CancelUnderEnrolled,
+		GroupWeight:          s.GroupWeight,
+		Progress:             s.Progress,
+		ctx:                  s.ctx,
+		bar:                  s.bar,
+		loadedStudents:       students,
+		locked:               s.locked,
+		lockedSections:       s.lockedSections,
+		groups:               s.groups,
+		groupsOf:             s.groupsOf,
+		apart:                s.apart,
+	}
+}
+
+// loadSections creates the sections of every requested course, numbered in the order of the events file
+func (s *Scheduler) loadSections() error {
 	for _, course := range s.DataLoader.Courses {
-		section := imp.NewSection(course, 0)
-		s.CourseNameToSection[course.CourseName] = section
+		rows := s.DataLoader.CourseEvents(course.CourseName)
+		if len(rows) == 0 {
+			return fmt.Errorf("course %q is not in %s, check that the names match in %s and %s", course.CourseName, data.EventsFile, data.EventsFile, data.RequestsFile)
+		}
+		for _, row := range rows {
+			for i := 0; i < row.Sections; i++ {
+				sectionCourse := course.DeepCopy()
+				sectionCourse.Section = len(s.CourseNameToSections[course.CourseName]) + 1
+				section := imp.NewSection(&sectionCourse, row.MaxStudents)
+				section.MinStudents = row.MinStudents
+				section.Instructor = row.Instructor
+				section.Room = row.Room
+				s.CourseNameToSections[course.CourseName] = append(s.CourseNameToSections[course.CourseName], section)
+			}
+		}
 	}
+	return nil
 }
 
 func (s *Scheduler) safeAddStudentToSection(student *imp.Student, section *imp.Section) bool {
@@ -54,106 +171,118 @@ func (s *Scheduler) safeAddStudentToSection(student *imp.Student, section *imp.S
 }
 
 func (s *Scheduler) FindFirstAvailableSectionForStudent(student *imp.Student, timeSlot string) *imp.Section {
-	var courseNames []string
-
-	if timeSlot == "AM" || timeSlot == "FullDay" {
-		courseNames = student.RequestedCourses.GetAMCourses()
-	} else if timeSlot == "PM" {
-		courseNames = student.RequestedCourses.GetPMCourses()
-	}
+	courseNames := student.RequestedCourses.GetCourses(timeSlot)
 
 	for _, courseName := range courseNames {
 		if courseName != "" {
-			section := s.CourseNameToSection[courseName]
-			if section != nil && len(section.Students) < section.MaxStudents {
+			section := s.openSection(student, s.CourseNameToSections[courseName])
+			if section != nil && section.Course.IsEligible(student.Grade) && section.Course.Occupies(timeSlot) && student.Free(section.Course) && !s.keptApart(student, courseName) {
 				return section
 			}
 		}
 	}
-	return s.GetFirstAvailableSectionWithoutRequest(timeSlot)
-}
-
-//func (s *Scheduler) FindFirstAvailableSectionForStudent(student *imp.Student, timeSlot string) *imp.Section {
-//	var courseNames []string
-//	priorityCourse := "Model UN Global Leadership Course on Promoting Democracy Worldwide" // Set the priority course name
-//
-//	if timeSlot == "AM" || timeSlot == "FullDay" {
-//		courseNames = student.RequestedCourses.GetAMCourses()
-//	} else if timeSlot == "PM" {
-//		courseNames = student.RequestedCourses.GetPMCourses()
-//	}
-//
-//	if student.StudentPriority > 1 {
-//		for _, courseName := range courseNames {
-//			if courseName == priorityCourse {
-//				section := s.CourseNameToSection[courseName]
-//				if section != nil && len(section.Students) < section.MaxStudents {
-//					return section
-//				}
-//			}
-//		}
-//	}
-//
-//	// Then check other courses
-//	for _, courseName := range courseNames {
-//		if courseName != "" && courseName != priorityCourse {
-//			section := s.CourseNameToSection[courseName]
-//			if section != nil && len(section.Students) < section.MaxStudents {
-//				return section
-//			}
-//		}
-//	}
-//
-//	// If no requested course is available, fall back to the first available section
-//	return s.GetFirstAvailableSectionWithoutRequest(timeSlot)
-//}
-
-func (s *Scheduler) GetFirstAvailableSectionWithoutRequest(timeSlot string) *imp.Section {
-	for _, section := range s.CourseNameToSection {
-		if section.Course.TimeSlot == timeSlot && len(section.Students) < section.MaxStudents {
-			return section
+	return s.GetFirstAvailableSectionWithoutRequest(student, timeSlot)
+}
+
+// sectionKey identifies a section by its course name and section number
+type sectionKey struct {
+	course  string
+	section int
+}
+
+func keyOf(course *imp.Course) sectionKey {
+	return sectionKey{course: course.CourseName, section: course.Section}
+}
+
+// leastFilledSection returns the section with room whose capacity is least taken, which spreads the
+// students of a course evenly over its sections. It returns nil when every section is full.
+func leastFilledSection(sections []*imp.Section) *imp.Section {
+	var least *imp.Section
+	for _, section := range sections {
+		if len(section.Students) < section.MaxStudents && (least == nil || section.Fill() < least.Fill()) {
+			least = section
+		}
+	}
+	return least
+}
+
+// GetFirstAvailableSectionWithoutRequest returns the first section of the time slot that has room
+// for the student, that the student's grade may take, that has no one the student must be kept apart
+// from and that the balance rules let the student join
+func (s *Scheduler) GetFirstAvailableSectionWithoutRequest(student *imp.Student, timeSlot string) *imp.Section {
+	for _, section := range s.sortedSections() {
+		if section.Course.TimeSlot == timeSlot && len(section.Students) < section.MaxStudents && section.Course.IsEligible(student.Grade) && !s.keptApart(student, section.Course.CourseName) {
+			if open := s.openSection(student, s.CourseNameToSections[section.Course.CourseName]); open != nil {
+				return open
+			}
 		}
 	}
 	return nil
 }
 
 func (s *Scheduler) AssignStudentsToSections() {
+	// Locked seats are taken before anyone else's, and the students keep them
+	s.seatLockedStudents()
+	s.fillOpenSlots()
+	s.gatherGroups()
+}
+
+// fillOpenSlots seats every student in the time slots they have no seat in yet, in the order the slots
+// take place, so a course of several slots is only chosen while all of them are open
+func (s *Scheduler) fillOpenSlots() {
 	assignCourses := func(student *imp.Student, timeSlot string) *imp.Section {
 		course := s.FindFirstAvailableSectionForStudent(student, timeSlot)
 		if course == nil {
-			secondChoice := s.GetFirstAvailableSectionWithoutRequest(timeSlot)
-			s.safeAddStudentToSection(student, secondChoice)
+			secondChoice := s.GetFirstAvailableSectionWithoutRequest(student, timeSlot)
+			if secondChoice != nil {
+				s.safeAddStudentToSection(student, secondChoice)
+			}
 			return secondChoice
 		}
 		s.safeAddStudentToSection(student, course)
 		return course
 	}
 	for _, student := range s.DataLoader.Students {
-		am_course := assignCourses(student, "AM")
-		if am_course != nil && am_course.Course.TimeSlot == "AM" {
-			assignCourses(student, "PM")
+		for _, timeSlot := range s.timeSlots() {
+			if student.EnrolledCourses[timeSlot].CourseName == "" {
+				assignCourses(student, timeSlot)
+			}
 		}
 	}
 }
 
+// timeSlots returns the time slots of the program in the order they take place
+func (s *Scheduler) timeSlots() []string {
+	return s.DataLoader.SlotTable().Slots
+}
+
+// ExtractByGradeAndShuffle orders the students by the tier of their grade, lowest first, and shuffles
+// the students within each tier
 func (s *Scheduler) ExtractByGradeAndShuffle() {
-	studentsByPriority := make(map[int][]*imp.Student, 3)
+	studentsByPriority := make(map[int][]*imp.Student)
+	var priorities []int
 
-	for _, student := range s.DataLoader.Students {
+	// Always start from the loaded order so that a trial only depends on its random source
+	for _, student := range s.loadedStudents {
 		student.UnrollEverything()
 		priority := student.StudentPriority
+		if _, ok := studentsByPriority[priority]; !ok {
+			priorities = append(priorities, priority)
+		}
 		studentsByPriority[priority] = append(studentsByPriority[priority], student)
 	}
+	sort.Ints(priorities)
 
-	for _, group := range studentsByPriority {
-		rand.Shuffle(len(group), func(i, j int) {
+	for _, priority := range priorities {
+		group := studentsByPriority[priority]
+		s.random().Shuffle(len(group), func(i, j int) {
 			group[i], group[j] = group[j], group[i]
 		})
 	}
 
-	var shuffledStudents []*imp.Student
-	for priority := 1; priority <= 3; priority++ {
-		shuffledStudents = append(shuffledStudents, studentsByPriority[priority]...)
+	shuffledStudents := make([]*imp.Student, 0, len(s.loadedStudents))
+	for _, priority := range priorities {
+		shuffledStudents = append(shuffledStudents, s.keepGroupsTogether(studentsByPriority[priority])...)
 	}
 
 	s.DataLoader.Students = shuffledStudents
@@ -170,6 +299,7 @@ func (s *Scheduler) ScoreSchedule() float64 {
 
 		studentCopy[i] = student.DeepCopy()
 	}
+	score += s.schedulePenalty(s.DataLoader.Students) + s.balancePenalty(s.sortedSections()...)
 
 	//if the current BestSchedule's score is lower than the current score, then we have a new best schedule
 	if s.BestSchedule == nil || score < s.BestSchedule.Score {
@@ -177,81 +307,240 @@ func (s *Scheduler) ScoreSchedule() float64 {
 			Students: studentCopy,
 			Sections: s.CourseNameToSectionToSlice(),
 			Score:    score,
+			Trial:    s.trial,
 		}
+		s.reportScore(score)
 	}
 	return score
 }
 
+// snapshot captures the current enrollment of every student and section as a schedule
+func (s *Scheduler) snapshot() *Schedule {
+	score := 0.0
+	students := make([]*imp.Student, len(s.DataLoader.Students))
+	for i, student := range s.DataLoader.Students {
+		score += student.SatisfactionScore()
+		students[i] = student.DeepCopy()
+	}
+	score += s.schedulePenalty(s.DataLoader.Students) + s.balancePenalty(s.sortedSections()...)
+	return &Schedule{
+		Students: students,
+		Sections: s.CourseNameToSectionToSlice(),
+		Score:    score,
+	}
+}
+
 func (s *Scheduler) ClearSections() {
-	for _, section := range s.CourseNameToSection {
+	for _, section := range s.sortedSections() {
 		section.ClearStudents()
 	}
 }
 
 func (s *Scheduler) CourseNameToSectionToSlice() []*imp.Section {
-	sections := make([]*imp.Section, 0, len(s.CourseNameToSection))
-	for _, section := range s.CourseNameToSection {
+	sections := make([]*imp.Section, 0, len(s.sortedSections()))
+	for _, section := range s.sortedSections() {
 		sections = append(sections, section.DeepCopy())
 	}
 	return sections
 }
 
+// sortedSections returns the sections ordered by course name and section number, which keeps runs
+// reproducible. The order is cached, so it has to be reset when CourseNameToSections changes.
+func (s *Scheduler) sortedSections() []*imp.Section {
+	if s.sectionOrder != nil {
+		return s.sectionOrder
+	}
+	sections := make([]*imp.Section, 0, len(s.CourseNameToSections))
+	for _, courseSections := range s.CourseNameToSections {
+		sections = append(sections, courseSections...)
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		if sections[i].Course.CourseName != sections[j].Course.CourseName {
+			return sections[i].Course.CourseName < sections[j].Course.CourseName
+		}
+		return sections[i].Course.Section < sections[j].Course.Section
+	})
+	s.sectionOrder = sections
+	return sections
+}
+
 func (s *Scheduler) Run(numIterations int) *Schedule {
+	schedule, err := s.RunContext(context.Background(), numIterations)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	return schedule
+}
+
+// RunContext runs the scheduling strategy and writes the best schedule to the results and sections
+// folders. It stops early and returns the context's error when the context is cancelled.
+func (s *Scheduler) RunContext(ctx context.Context, numIterations int) (*Schedule, error) {
+	if s.Anneal != nil {
+		if err := s.Anneal.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	sectionFolderPath := "sections/"
 	resultsFolderPath := "results/"
 	currentTime := time.Now()
 
 	// Ensure necessary directories exist
 	if err := ensureDirectory(sectionFolderPath); err != nil {
-		fmt.Println("Failed to create section directory:", err)
-		return nil
+		return nil, fmt.Errorf("failed to create section directory: %w", err)
 	}
 	if err := ensureDirectory(resultsFolderPath); err != nil {
-		fmt.Println("Failed to create results directory:", err)
-		return nil
+		return nil, fmt.Errorf("failed to create results directory: %w", err)
+	}
+
+	strategy := s.Strategy
+	if strategy == nil {
+		strategy, _ = LookupStrategy(DefaultStrategy)
+	}
+	weights := s.Weights
+	if weights == nil {
+		weights = &imp.DefaultRankWeights
+	}
+	for _, student := range s.DataLoader.Students {
+		student.Weights = weights
+	}
+	if s.BestSchedule != nil && !s.BestSchedule.Weights.Equal(weights) {
+		// Scores under different weights can't be compared
+		s.BestSchedule = nil
+	}
+
+	// Initialize progress bar for tracking, unless the caller follows the progress itself
+	if s.Progress == nil {
+		s.bar = progressbar.Default(int64(numIterations))
+	} else {
+		s.Progress.start(numIterations)
+	}
+	s.ctx = ctx
+	s.infeasible = nil
+	defer func() {
+		s.bar = nil
+		s.ctx = nil
+	}()
+
+	var schedule *Schedule
+	if s.CancelUnderEnrolled {
+		schedule = s.scheduleWithCancellations(strategy, numIterations)
+	} else {
+		schedule = strategy.Schedule(s, numIterations)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if schedule == nil && s.infeasible != nil {
+		return nil, s.infeasible
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("the %s strategy did not produce a schedule", strategy.Name())
 	}
+	if err := s.keptApartError(schedule.Students, schedule.Sections); err != nil {
+		return nil, err
+	}
+	s.BestSchedule = schedule
+	s.BestSchedule.Weights = weights
+	s.BestSchedule.Groups = s.groupResults(s.BestSchedule.Students)
 
 	// Setup CSV files for results and sections
-	resultsFile, err := setupCSVFile(resultsFolderPath, "results_", currentTime)
+	resultsFile, runName, err := createRunFile(resultsFolderPath, "results_", currentTime)
 	if err != nil {
-		fmt.Println("Error setting up results CSV file:", err)
-		return nil
+		return nil, fmt.Errorf("error setting up results CSV file: %w", err)
 	}
 	defer resultsFile.Close()
 	resultsWriter := csv.NewWriter(resultsFile)
 	defer resultsWriter.Flush()
 
-	sectionFile, err := setupCSVFile(sectionFolderPath, "sections_", currentTime)
+	sectionFile, err := setupCSVFile(sectionFolderPath, "sections_", runName)
 	if err != nil {
-		fmt.Println("Error setting up section CSV file:", err)
-		return nil
+		return nil, fmt.Errorf("error setting up section CSV file: %w", err)
 	}
 	defer sectionFile.Close()
 	sectionWriter := csv.NewWriter(sectionFile)
 	defer sectionWriter.Flush()
 
-	// Initialize progress bar for tracking
-	bar := progressbar.Default(int64(numIterations))
+	weightsFile, err := setupCSVFile(resultsFolderPath, "weights_", runName)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up weights CSV file: %w", err)
+	}
+	defer weightsFile.Close()
+	weightsWriter := csv.NewWriter(weightsFile)
+	defer weightsWriter.Flush()
 
-	for i := 0; i < numIterations; i++ {
-		err := bar.Add(1)
+	// Output schedule and section information to CSV files
+	if err := s.outputSchedule(resultsWriter, sectionWriter, s.BestSchedule); err != nil {
+		return nil, fmt.Errorf("error writing to CSV file: %w", err)
+	}
+	if err := outputWeights(weightsWriter, weights, s.DataLoader.Choices()); err != nil {
+		return nil, fmt.Errorf("error writing to CSV file: %w", err)
+	}
+	if s.CancelUnderEnrolled {
+		cancelledFile, err := setupCSVFile(resultsFolderPath, "cancelled_", runName)
 		if err != nil {
-			return nil
+			return nil, fmt.Errorf("error setting up cancelled CSV file: %w", err)
+		}
+		defer cancelledFile.Close()
+		cancelledWriter := csv.NewWriter(cancelledFile)
+		defer cancelledWriter.Flush()
+		if err := outputCancellations(cancelledWriter, s.BestSchedule.Cancelled); err != nil {
+			return nil, fmt.Errorf("error writing to CSV file: %w", err)
 		}
-		s.ExtractByGradeAndShuffle()
-		s.AssignStudentsToSections()
-		s.ScoreSchedule()
-		s.ClearSections()
 	}
 
-	// Output schedule and section information to CSV files
-	if err := outputSchedule(resultsWriter, sectionWriter, s.BestSchedule); err != nil {
-		fmt.Println("Error writing to CSV file:", err)
-		return nil
+	if len(s.groups) > 0 {
+		groupsFile, err := setupCSVFile(resultsFolderPath, "groups_", runName)
+		if err != nil {
+			return nil, fmt.Errorf("error setting up groups CSV file: %w", err)
+		}
+		defer groupsFile.Close()
+		groupsWriter := csv.NewWriter(groupsFile)
+		defer groupsWriter.Flush()
+		if err := outputGroups(groupsWriter, s.BestSchedule.Groups); err != nil {
+			return nil, fmt.Errorf("error writing to CSV file: %w", err)
+		}
+	}
+
+	manifest, err := s.newManifest(numIterations, strategy, s.BestSchedule)
+	if err == nil {
+		err = writeManifest(resultsFolderPath, runName, manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error writing run manifest: %w", err)
+	}
+
+	return s.BestSchedule, nil
+}
+
+// random returns the random source of the current trial
+func (s *Scheduler) random() *rand.Rand {
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(s.Seed))
+	}
+	return s.rng
+}
+
+// tick advances the progress by one iteration
+func (s *Scheduler) tick() {
+	if s.bar != nil {
+		_ = s.bar.Add(1)
+	}
+	if s.Progress != nil {
+		s.Progress.step()
 	}
+}
 
-	fmt.Println("Best schedule score:", s.BestSchedule.Score)
-	return s.BestSchedule
+// reportScore records the score of a schedule a strategy found, so the progress shows the best one so far
+func (s *Scheduler) reportScore(score float64) {
+	if s.Progress != nil {
+		s.Progress.score(score)
+	}
+}
+
+// stopped reports whether the run was cancelled, in which case strategies should return early
+func (s *Scheduler) stopped() bool {
+	return s.ctx != nil && s.ctx.Err() != nil
 }
 
 func ensureDirectory(path string) error {
@@ -262,25 +551,72 @@ func ensureDirectory(path string) error {
 	return nil // Directory already exists
 }
 
-func setupCSVFile(path, prefix string, currentTime time.Time) (*os.File, error) {
-	filename := fmt.Sprintf("%s%s%s.csv", path, prefix, currentTime.Format("2006-01-02_15-04-05"))
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		// File doesn't exist, create it
-		return os.Create(filename)
+// createRunFile creates the first file of a run. When another run already used the timestamp it adds
+// a counter, and returns the name that the other files of the run should share.
+func createRunFile(path, prefix string, currentTime time.Time) (*os.File, string, error) {
+	runName := currentTime.Format("2006-01-02_15-04-05")
+	for n := 2; ; n++ {
+		file, err := os.OpenFile(fmt.Sprintf("%s%s%s.csv", path, prefix, runName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			return file, runName, err
+		}
+		runName = fmt.Sprintf("%s_%d", currentTime.Format("2006-01-02_15-04-05"), n)
 	}
-	// File already exists (this might be ideal if you want to append, but you would need to adjust other code)
-	return os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
 }
 
-func outputSchedule(resultsWriter, sectionWriter *csv.Writer, schedule *Schedule) error {
+func setupCSVFile(path, prefix, runName string) (*os.File, error) {
+	return os.Create(fmt.Sprintf("%s%s%s.csv", path, prefix, runName))
+}
+
+// outputSchedule writes the schedule of every student and the roster of every section. A student has a
+// column for the course of every time slot, followed by one for every time slot of the courses that take
+// up several. The roster is followed by its composition by grade and by the attributes of the balance
+// rules, and by the balance rules it breaks when there are any.
+func (s *Scheduler) outputSchedule(resultsWriter, sectionWriter *csv.Writer, schedule *Schedule) error {
+	timeSlots := append([]string(nil), s.timeSlots()...)
+	var spans []string
+	seen := make(map[string]bool)
+	for _, section := range schedule.Sections {
+		if section.Course.MultiSlot() && !seen[section.Course.TimeSlot] {
+			seen[section.Course.TimeSlot] = true
+			spans = append(spans, section.Course.TimeSlot)
+		}
+	}
+	sort.Strings(spans)
+
 	// Writing header rows
-	if err := resultsWriter.Write([]string{"Email", "First Name", "Last Name", "Grade", "AM Course", "PM Course", "FD Course", "SS Score"}); err != nil {
+	resultsHeader := []string{"Email", "First Name", "Last Name", "Grade"}
+	for _, timeSlot := range append(timeSlots, spans...) {
+		resultsHeader = append(resultsHeader, timeSlot+" Course")
+	}
+	if err := resultsWriter.Write(append(resultsHeader, "SS Score")); err != nil {
 		return err
 	}
-	if err := sectionWriter.Write([]string{"Course Name", "Max Students", "Enrolled Students", "Student Roster"}); err != nil {
+	attributes := s.balanceAttributes()
+	sectionHeader := []string{"Course Name", "Section", "Instructor", "Room", "Max Students", "Enrolled Students", "Student Roster"}
+	for _, attribute := range attributes {
+		sectionHeader = append(sectionHeader, "Composition by "+attribute)
+	}
+	rules := len(s.balanceRules()) > 0
+	if rules {
+		sectionHeader = append(sectionHeader, "Balance Issues")
+	}
+	if err := sectionWriter.Write(sectionHeader); err != nil {
 		return err
 	}
 
+	// Courses with more than one section name the section of every student
+	sectionCounts := make(map[string]int)
+	for _, section := range schedule.Sections {
+		sectionCounts[section.Course.CourseName]++
+	}
+	courseName := func(course imp.Course) string {
+		if sectionCounts[course.CourseName] > 1 {
+			return fmt.Sprintf("%s (section %d)", course.CourseName, course.Section)
+		}
+		return course.CourseName
+	}
+
 	// Writing data rows
 	for _, student := range schedule.Students {
 		record := []string{
@@ -288,11 +624,24 @@ func outputSchedule(resultsWriter, sectionWriter *csv.Writer, schedule *Schedule
 			student.StudentFirstName,
 			student.StudentLastName,
 			student.Grade,
-			student.EnrolledCourses.AMCourse.CourseName,
-			student.EnrolledCourses.PMCourse.CourseName,
-			student.EnrolledCourses.FullDayCourse.CourseName,
-			fmt.Sprintf("%.6f", student.SatisfactionScore()),
 		}
+		for _, timeSlot := range timeSlots {
+			if course := student.EnrolledCourses[timeSlot]; !course.MultiSlot() {
+				record = append(record, courseName(course))
+			} else {
+				record = append(record, "")
+			}
+		}
+		for _, span := range spans {
+			var name string
+			for _, course := range student.EnrolledCourses.Courses() {
+				if course.MultiSlot() && course.TimeSlot == span {
+					name = courseName(course)
+				}
+			}
+			record = append(record, name)
+		}
+		record = append(record, fmt.Sprintf("%.6f", student.SatisfactionScore()))
 		if err := resultsWriter.Write(record); err != nil {
 			return err
 		}
@@ -306,13 +655,44 @@ func outputSchedule(resultsWriter, sectionWriter *csv.Writer, schedule *Schedule
 		studentRoster := strings.Join(studentNames, ", ")
 		record := []string{
 			section.Course.CourseName,
+			fmt.Sprintf("%d", section.Course.Section),
+			section.Instructor,
+			section.Room,
 			fmt.Sprintf("%d", section.MaxStudents),
 			fmt.Sprintf("%d", len(section.Students)),
 			fmt.Sprintf("\"%s\"", studentRoster),
 		}
+		for _, attribute := range attributes {
+			record = append(record, composition(section, attribute))
+		}
+		if rules {
+			var issues []string
+			for _, issue := range s.balanceIssues(section) {
+				issues = append(issues, issue.String())
+			}
+			record = append(record, strings.Join(issues, "; "))
+		}
 		if err := sectionWriter.Write(record); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// outputWeights writes the weight of every rank, and of the ranks up to the number of choices in the
+// requests that reuse the last weight
+func outputWeights(weightsWriter *csv.Writer, weights *imp.RankWeights, choices int) error {
+	if err := weightsWriter.Write([]string{"Choice", "Weight"}); err != nil {
+		return err
+	}
+	ranks := len(weights.Ranks)
+	if choices > ranks {
+		ranks = choices
+	}
+	for i := 0; i < ranks; i++ {
+		if err := weightsWriter.Write([]string{strconv.Itoa(i + 1), strconv.FormatFloat(weights.Cost(i), 'g', -1, 64)}); err != nil {
+			return err
+		}
+	}
+	return weightsWriter.Write([]string{"Unrequested", strconv.FormatFloat(weights.Unrequested, 'g', -1, 64)})
+}
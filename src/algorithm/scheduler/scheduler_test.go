@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+)
+
+const choiceHeaders = "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+	"AM Course - 1st Choice. (Drop down option),AM Course - 2nd Choice. (Drop down option)," +
+	"PM Course - 1st Choice. (Drop down option),PM Course - 2nd Choice. (Drop down option)\n"
+
+// newTestScheduler loads a scheduler from the contents of a requests and an events file
+func newTestScheduler(t *testing.T, requests, events string) *Scheduler {
+	t.Helper()
+	loader, err := data.Load([]byte(requests), []byte(events), data.Options{})
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	s, err := NewSchedulerWithData(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Workers = 1
+	s.Seed = 1
+	return s
+}
+
+// unseated counts the time slots the students of the schedule have no course in
+func unseated(schedule *Schedule) int {
+	count := 0
+	for _, student := range schedule.Students {
+		count += len(student.UnseatedSlots())
+	}
+	return count
+}
+
+// Only the junior may take Y, so the freshman is only seated when the junior, who is scheduled first,
+// settles for their second choice
+const crowdedRequests = choiceHeaders +
+	"j@x.org,J,J,Junior,X,Y,\n" +
+	"f@x.org,F,F,Freshman,X,,\n"
+
+const crowdedEvents = "Name,Max Students,Time Slot,Grades\n" +
+	"X,1,AM,\n" +
+	"Y,1,AM,Junior\n"
+
+func TestUnseatedStudentCostsMoreThanAWorseChoice(t *testing.T) {
+	greedy := newTestScheduler(t, crowdedRequests, crowdedEvents)
+	left := GreedyStrategy{}.Schedule(greedy, 10)
+	if unseated(left) != 1 {
+		t.Fatalf("greedy left %d slots unseated, want the freshman's", unseated(left))
+	}
+	if left.Score < imp.DefaultRankWeights.Unseated() {
+		t.Errorf("greedy score %g with an unseated student, want at least %g", left.Score, imp.DefaultRankWeights.Unseated())
+	}
+
+	optimal := newTestScheduler(t, crowdedRequests, crowdedEvents)
+	seated := OptimalStrategy{}.Schedule(optimal, 10)
+	if unseated(seated) != 0 || seated.Score != 1 {
+		t.Errorf("optimal scored %g with %d unseated slots, want 1 with everyone seated", seated.Score, unseated(seated))
+	}
+	if seated.Score >= left.Score {
+		t.Errorf("seating everyone scored %g, not less than %g for leaving a student out", seated.Score, left.Score)
+	}
+}
+
+func TestTimeSlotsWithoutCoursesAreNotCharged(t *testing.T) {
+	s := newTestScheduler(t, crowdedRequests, crowdedEvents)
+	for _, student := range s.DataLoader.Students {
+		if strings.Join(student.TimeSlots, ",") != "AM" {
+			t.Errorf("%s should have a course in %v, want only AM, the only slot with courses", student.StudentEmail, student.TimeSlots)
+		}
+	}
+}
+
+func TestNewSchedulerRejectsCoursesMissingFromTheEvents(t *testing.T) {
+	loader := &data.DataLoader{Courses: []*imp.Course{imp.NewCourse("Pottery", "AM")}}
+	if _, err := NewSchedulerWithData(loader); err == nil || !strings.Contains(err.Error(), "Pottery") {
+		t.Errorf("NewSchedulerWithData() error = %v, want one naming the course", err)
+	}
+}
+
+func TestIneligibleStudentsStayOutOfRestrictedCourses(t *testing.T) {
+	// The freshman asks for the juniors only course Y, which has room, and has to be seated in X or Z
+	requests := choiceHeaders +
+		"j@x.org,J,J,Junior,X,Z,,\n" +
+		"f@x.org,F,F,Freshman,Y,X,,\n"
+	events := "Name,Max Students,Time Slot,Grades\n" +
+		"X,1,AM,\n" +
+		"Y,5,AM,Junior\n" +
+		"Z,5,AM,\n"
+	for _, strategy := range []Strategy{GreedyStrategy{}, OptimalStrategy{}, AnnealStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			s := newTestScheduler(t, requests, events)
+			s.Anneal = &AnnealOptions{StartTemperature: 4, EndTemperature: 0.05, Steps: 20000}
+			schedule := strategy.Schedule(s, 5)
+			for _, student := range schedule.Students {
+				if got := student.EnrolledCourses["AM"].CourseName; student.Grade == "Freshman" && (got == "Y" || got == "") {
+					t.Errorf("the freshman is in %q, want X or Z", got)
+				}
+			}
+		})
+	}
+}
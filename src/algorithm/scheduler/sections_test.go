@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Five students want Robotics, which is taught in two rooms of two and three seats, and a sixth
+// student's request is left for the next choice
+const sectionRequests = choiceHeaders +
+	"a@x.org,A,A,Junior,Robotics,Chess,,\n" +
+	"b@x.org,B,B,Junior,Robotics,Chess,,\n" +
+	"c@x.org,C,C,Junior,Robotics,Chess,,\n" +
+	"d@x.org,D,D,Junior,Robotics,Chess,,\n" +
+	"e@x.org,E,E,Junior,Robotics,Chess,,\n" +
+	"f@x.org,F,F,Junior,Robotics,Chess,,\n"
+
+const sectionEvents = "Name,Max Students,Time Slot,Room,Sections\n" +
+	"Robotics,2,AM,Lab 1,1\n" +
+	"Robotics,3,AM,Lab 2,1\n" +
+	"Chess,4,AM,Library,2\n"
+
+func TestCoursesHaveASectionPerRoom(t *testing.T) {
+	s := newTestScheduler(t, sectionRequests, sectionEvents)
+	type row struct {
+		Number      int
+		MaxStudents int
+		Room        string
+	}
+	var got []row
+	for _, name := range []string{"Robotics", "Chess"} {
+		for _, section := range s.CourseNameToSections[name] {
+			got = append(got, row{section.Course.Section, section.MaxStudents, section.Room})
+		}
+	}
+	want := []row{{1, 2, "Lab 1"}, {2, 3, "Lab 2"}, {1, 4, "Library"}, {2, 4, "Library"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnySectionSatisfiesARequest(t *testing.T) {
+	for _, strategy := range []Strategy{GreedyStrategy{}, OptimalStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			schedule := strategy.Schedule(newTestScheduler(t, sectionRequests, sectionEvents), 5)
+			enrolled := make(map[string][]int)
+			for _, section := range schedule.Sections {
+				enrolled[section.Course.CourseName] = append(enrolled[section.Course.CourseName], len(section.Students))
+			}
+			if unseated(schedule) != 0 || schedule.Score != 1 {
+				t.Errorf("scored %g with %d unseated slots, want one student in their second choice", schedule.Score, unseated(schedule))
+			}
+			if robotics := enrolled["Robotics"]; !reflect.DeepEqual(robotics, []int{2, 3}) {
+				t.Errorf("the Robotics sections have %v students, want both full", robotics)
+			}
+		})
+	}
+}
+
+func TestGreedyBalancesTheSectionsOfACourse(t *testing.T) {
+	requests := choiceHeaders +
+		"a@x.org,A,A,Junior,Chess,,,\n" +
+		"b@x.org,B,B,Junior,Chess,,,\n" +
+		"c@x.org,C,C,Junior,Chess,,,\n" +
+		"d@x.org,D,D,Junior,Chess,,,\n"
+	schedule := GreedyStrategy{}.Schedule(newTestScheduler(t, requests, "Name,Max Students,Time Slot,Sections\nChess,4,AM,2\n"), 1)
+	for _, section := range schedule.Sections {
+		if len(section.Students) != 2 {
+			t.Errorf("section %d of Chess has %d students, want the 4 students split evenly", section.Course.Section, len(section.Students))
+		}
+	}
+}
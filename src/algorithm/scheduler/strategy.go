@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const DefaultStrategy = "greedy"
+
+// Strategy builds a schedule from the students and sections loaded into a Scheduler.
+type Strategy interface {
+	Name() string
+	Schedule(s *Scheduler, numIterations int) *Schedule
+}
+
+var strategies = make(map[string]Strategy)
+
+// RegisterStrategy makes a strategy available by name to the CLI and HTTP API
+func RegisterStrategy(strategy Strategy) {
+	strategies[strategy.Name()] = strategy
+}
+
+// LookupStrategy returns the registered strategy with the given name, or the default one if name is empty
+func LookupStrategy(name string) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategy, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, available strategies: %s", name, strings.Join(StrategyNames(), ", "))
+	}
+	return strategy, nil
+}
+
+// StrategyNames returns the names of all registered strategies in sorted order
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLookupStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"", DefaultStrategy, true},
+		{"greedy", "greedy", true},
+		{"optimal", "optimal", true},
+		{"anneal", "anneal", true},
+		{"Greedy", "", false},
+		{"random", "", false},
+	}
+	for _, test := range tests {
+		strategy, err := LookupStrategy(test.name)
+		if !test.ok {
+			if err == nil || !strings.Contains(err.Error(), strings.Join(StrategyNames(), ", ")) {
+				t.Errorf("LookupStrategy(%q) error = %v, want one listing the strategies", test.name, err)
+			}
+			continue
+		}
+		if err != nil || strategy.Name() != test.want {
+			t.Errorf("LookupStrategy(%q) = %v, %v, want %s", test.name, strategy, err, test.want)
+		}
+	}
+}
+
+func TestStrategyNamesAreSorted(t *testing.T) {
+	if names, want := StrategyNames(), []string{"anneal", "greedy", "optimal"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("StrategyNames() = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,164 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ApartFile is the confidential table of students who must not share a course that is used when it
+// exists in the working directory. Its students are never written to the results.
+const ApartFile = "apart.csv"
+
+// KeepApart keeps every two of its students out of the same course, in every section and time slot
+type KeepApart struct {
+	Emails []string `json:"emails"`
+	// Line is the line of the keep-apart file the constraint was read from
+	Line int `json:"line"`
+}
+
+// ReadKeepApart loads the keep-apart constraints from a CSV file. A missing file has no constraints.
+func ReadKeepApart(path string) ([]KeepApart, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeepApart(contents)
+}
+
+// ParseKeepApart reads keep-apart constraints from a CSV file with a header row. Every column whose name
+// starts with "email" holds a student, so a row keeps a pair or a larger set of students apart.
+func ParseKeepApart(contents []byte) ([]KeepApart, error) {
+	reader := csv.NewReader(bytes.NewReader(contents))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid keep-apart constraints: %w", err)
+	}
+
+	var emails []int
+	for i, name := range header {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))), "email") {
+			emails = append(emails, i)
+		}
+	}
+	if len(emails) < 2 {
+		return nil, errors.New("invalid keep-apart constraints: the header row needs at least two email columns")
+	}
+
+	var constraints []KeepApart
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid keep-apart constraints: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		constraint := KeepApart{Line: line}
+		for _, i := range emails {
+			if i < len(record) && strings.TrimSpace(record[i]) != "" {
+				constraint.Emails = append(constraint.Emails, strings.TrimSpace(record[i]))
+			}
+		}
+		if len(constraint.Emails) > 0 {
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints, nil
+}
+
+// apartProblem is a keep-apart constraint that can't be met
+type apartProblem struct {
+	line    int
+	message string
+}
+
+// checkKeepApart matches the keep-apart constraints to the students of the requests and returns the
+// constraints that can be met together with the problems of the others. Constraints fail when a student
+// is unknown, when fewer than two different students are left, or when two of the students are locked
+// into the same course.
+func checkKeepApart(constraints []KeepApart, requests []*algorithm.Request, locks []Lock, courses []events.Course) ([]KeepApart, []apartProblem) {
+	students := make(map[string]bool, len(requests))
+	for _, request := range requests {
+		students[NormalizeEmail(request.Email)] = true
+	}
+	byNormalized := make(map[string]string)
+	for _, course := range courses {
+		byNormalized[NormalizeCourseName(course.Name)] = course.Name
+	}
+	lockedInto := make(map[string][]string)
+	for _, lock := range locks {
+		course := lock.Course
+		if name, ok := byNormalized[NormalizeCourseName(course)]; ok {
+			course = name
+		}
+		email := NormalizeEmail(lock.Email)
+		lockedInto[email] = append(lockedInto[email], course)
+	}
+
+	var valid []KeepApart
+	var problems []apartProblem
+	for _, constraint := range constraints {
+		fail := func(format string, args ...interface{}) {
+			problems = append(problems, apartProblem{line: constraint.Line, message: fmt.Sprintf(format, args...)})
+		}
+		seen := make(map[string]bool)
+		var emails []string
+		ok := true
+		for _, email := range constraint.Emails {
+			normalized := NormalizeEmail(email)
+			if !students[normalized] {
+				fail("no student with the email %q is in %s", email, RequestsFile)
+				ok = false
+				break
+			}
+			if !seen[normalized] {
+				seen[normalized] = true
+				emails = append(emails, email)
+			}
+		}
+		if !ok {
+			continue
+		}
+		if len(emails) < 2 {
+			fail("a keep-apart constraint needs at least two different students")
+			continue
+		}
+	pairs:
+		for i, first := range emails {
+			for _, second := range emails[i+1:] {
+				for _, course := range lockedInto[NormalizeEmail(first)] {
+					for _, other := range lockedInto[NormalizeEmail(second)] {
+						if course == other {
+							fail("%s and %s must be kept apart but are both locked into %q in %s", first, second, course, LocksFile)
+							ok = false
+							break pairs
+						}
+					}
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		constraint.Emails = emails
+		valid = append(valid, constraint)
+	}
+	return valid, problems
+}
@@ -0,0 +1,175 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// BalanceFile is the table of section balance rules that is used when it exists in the working directory
+const BalanceFile = "balance.json"
+
+// GradeAttribute is the attribute of balance rules that stands for the grade of the students
+const GradeAttribute = "grade"
+
+// DefaultBalanceWeight is what every student over a maximum or under a minimum adds to the score when a
+// rule has no weight, as much as getting a fifth instead of a first choice under the default rank weights
+const DefaultBalanceWeight = 8.0
+
+// BalanceRules limits how many students with the same answer to a question a section may hold
+type BalanceRules struct {
+	Rules []BalanceRule `json:"rules"`
+}
+
+// BalanceRule limits the students of every section who share a value of an attribute. The attribute
+// is the grade, or the header of a column of the requests that isn't mapped to a request field.
+type BalanceRule struct {
+	Attribute string `json:"attribute"`
+	// Value limits only the students with this value, matched regardless of case, and every value when empty
+	Value string `json:"value,omitempty"`
+	// Max is the most students with a value a section may hold, and MaxShare the largest share of its seats
+	// they may take. The tighter of the two applies.
+	Max      *int    `json:"max,omitempty"`
+	MaxShare float64 `json:"maxShare,omitempty"`
+	// Min is the fewest students with the value a section should hold, which can only be penalized
+	Min int `json:"min,omitempty"`
+	// Courses limits the rule to some courses, every course when empty
+	Courses []string `json:"courses,omitempty"`
+	// Hard keeps every assignment from going over the maximum instead of penalizing it
+	Hard bool `json:"hard,omitempty"`
+	// Weight is what every student over the maximum or under the minimum adds to the score,
+	// DefaultBalanceWeight when zero
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// ReadBalanceRules loads the balance rules from a JSON file. A missing file has no rules.
+func ReadBalanceRules(path string) (*BalanceRules, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseBalanceRules(contents)
+}
+
+// ParseBalanceRules reads balance rules from JSON and checks that every rule limits something
+func ParseBalanceRules(contents []byte) (*BalanceRules, error) {
+	rules := &BalanceRules{}
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(rules); err != nil {
+		return nil, fmt.Errorf("invalid balance rules: %w", err)
+	}
+	for i, rule := range rules.Rules {
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("invalid balance rules: rule %d: %s", i+1, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case strings.TrimSpace(rule.Attribute) == "":
+			return nil, fail("it has no attribute")
+		case rule.Max == nil && rule.MaxShare == 0 && rule.Min == 0:
+			return nil, fail("it has no max, maxShare or min")
+		case rule.Max != nil && *rule.Max < 0, rule.Min < 0, rule.Weight < 0:
+			return nil, fail("max, min and weight can't be negative")
+		case rule.MaxShare < 0 || rule.MaxShare > 1:
+			return nil, fail("maxShare %g is not between 0 and 1", rule.MaxShare)
+		case rule.Min > 0 && rule.Value == "":
+			return nil, fail("a min needs a value")
+		case rule.Max != nil && rule.Min > *rule.Max:
+			return nil, fail("min %d is over max %d", rule.Min, *rule.Max)
+		}
+	}
+	return rules, nil
+}
+
+// Limit returns the most students with a value the rule lets a section with the given number of seats
+// hold, or -1 when the rule has no maximum
+func (r *BalanceRule) Limit(seats int) int {
+	limit := -1
+	if r.Max != nil {
+		limit = *r.Max
+	}
+	if r.MaxShare > 0 {
+		share := int(r.MaxShare * float64(seats))
+		if limit < 0 || share < limit {
+			limit = share
+		}
+	}
+	return limit
+}
+
+// AppliesTo reports whether the rule limits the sections of the course
+func (r *BalanceRule) AppliesTo(course string) bool {
+	if len(r.Courses) == 0 {
+		return true
+	}
+	for _, name := range r.Courses {
+		if name == course || NormalizeCourseName(name) == NormalizeCourseName(course) {
+			return true
+		}
+	}
+	return false
+}
+
+// Penalty returns the weight of every student over the maximum or under the minimum
+func (r *BalanceRule) Penalty() float64 {
+	if r.Weight == 0 {
+		return DefaultBalanceWeight
+	}
+	return r.Weight
+}
+
+// Attribute returns the value of a balance rule attribute for a request
+func Attribute(request *algorithm.Request, attribute string) string {
+	if strings.EqualFold(strings.TrimSpace(attribute), GradeAttribute) {
+		return strings.TrimSpace(request.Grade)
+	}
+	if value, ok := request.Attributes[strings.TrimSpace(attribute)]; ok {
+		return value
+	}
+	for header, value := range request.Attributes {
+		if strings.EqualFold(header, strings.TrimSpace(attribute)) {
+			return value
+		}
+	}
+	return ""
+}
+
+// checkBalance returns the problems of balance rules that name an attribute no request answers or a
+// course that isn't in the events
+func checkBalance(rules *BalanceRules, requests []*algorithm.Request, courses []events.Course) []string {
+	if rules == nil {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, course := range courses {
+		known[NormalizeCourseName(course.Name)] = true
+	}
+	var problems []string
+	for i, rule := range rules.Rules {
+		answered := strings.EqualFold(strings.TrimSpace(rule.Attribute), GradeAttribute)
+		for _, request := range requests {
+			if answered {
+				break
+			}
+			answered = Attribute(request, rule.Attribute) != ""
+		}
+		if !answered {
+			problems = append(problems, fmt.Sprintf("rule %d: no request has an answer in a %q column", i+1, rule.Attribute))
+		}
+		for _, course := range rule.Courses {
+			if !known[NormalizeCourseName(course)] {
+				problems = append(problems, fmt.Sprintf("rule %d: course %q is not in %s", i+1, course, EventsFile))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBalanceRules(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		// problem is part of the error, empty when the rules are valid
+		problem string
+	}{
+		{"max share of any grade", `{"rules": [{"attribute": "grade", "maxShare": 0.5, "hard": true}]}`, ""},
+		{"min and max of a value", `{"rules": [{"attribute": "School", "value": "North", "min": 2, "max": 6, "courses": ["Robotics"]}]}`, ""},
+		{"unknown field", `{"rules": [{"attribute": "grade", "maximum": 3}]}`, "unknown field"},
+		{"no attribute", `{"rules": [{"max": 3}]}`, "rule 1: it has no attribute"},
+		{"no limit", `{"rules": [{"attribute": "grade", "hard": true}]}`, "no max, maxShare or min"},
+		{"negative max", `{"rules": [{"attribute": "grade", "max": -1}]}`, "can't be negative"},
+		{"share over one", `{"rules": [{"attribute": "grade", "maxShare": 1.5}]}`, "not between 0 and 1"},
+		{"min of every value", `{"rules": [{"attribute": "grade", "min": 2}]}`, "a min needs a value"},
+		{"min over max", `{"rules": [{"attribute": "grade", "value": "Junior", "min": 4, "max": 3}]}`, "min 4 is over max 3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseBalanceRules([]byte(test.file))
+			if test.problem == "" && err != nil || test.problem != "" && (err == nil || !strings.Contains(err.Error(), test.problem)) {
+				t.Errorf("ParseBalanceRules() error = %v, want %q", err, test.problem)
+			}
+		})
+	}
+}
+
+func TestBalanceRuleLimit(t *testing.T) {
+	three := 3
+	tests := []struct {
+		rule  BalanceRule
+		seats int
+		want  int
+	}{
+		{BalanceRule{Min: 1}, 10, -1},
+		{BalanceRule{Max: &three}, 10, 3},
+		{BalanceRule{MaxShare: 0.5}, 9, 4},
+		{BalanceRule{Max: &three, MaxShare: 0.5}, 10, 3},
+		{BalanceRule{Max: &three, MaxShare: 0.2}, 10, 2},
+	}
+	for _, test := range tests {
+		if got := test.rule.Limit(test.seats); got != test.want {
+			t.Errorf("%+v Limit(%d) = %d, want %d", test.rule, test.seats, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColumnMapping names the header of the requests file column that holds each field of a request.
+// Choices lists the headers of the ranked choices of every time slot, first choice first, and so
+// decides how many choices every request has. AM and PM are short for the choices of those slots.
+type ColumnMapping struct {
+	Email     string              `json:"email"`
+	FirstName string              `json:"firstName"`
+	LastName  string              `json:"lastName"`
+	Grade     string              `json:"grade"`
+	AM        []string            `json:"am,omitempty"`
+	PM        []string            `json:"pm,omitempty"`
+	Choices   map[string][]string `json:"choices,omitempty"`
+	// Timestamp is the optional column holding when the form was submitted
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// ColumnsInput names a custom column mapping among the inputs of a run
+const ColumnsInput = "columns"
+
+// DefaultColumnMapping matches the headers of the Google Form the requests have been exported from so far.
+// When no mapping is given the choice headers are detected instead, so the form can ask for any number
+// of choices.
+var DefaultColumnMapping = ColumnMapping{
+	Email:     "Email Address",
+	FirstName: "Students First Name",
+	LastName:  "Students Last Name",
+	Grade:     "Grade in school this year",
+	AM: []string{
+		"AM Course - 1st Choice. (Drop down option)",
+		"AM Course - 2nd Choice. (Drop down option)",
+		"AM Course - 3rd Choice. (Drop down option)",
+		"AM Course - 4th Choice. (Drop down option)",
+		"AM Course - 5th Choice. (Drop down option)",
+	},
+	PM: []string{
+		"PM Course - 1st Choice. (Drop down option)",
+		"PM Course - 2nd Choice. (Drop down option)",
+		"PM Course - 3rd Choice. (Drop down option)",
+		"PM Course - 4th Choice. (Drop down option)",
+		"PM Course - 5th Choice. (Drop down option)",
+	},
+	Timestamp: "Timestamp",
+}
+
+// ReadColumnMapping loads a column mapping from a JSON file
+func ReadColumnMapping(path string) (*ColumnMapping, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseColumnMapping(contents)
+}
+
+// ParseColumnMapping reads a column mapping in JSON and checks that it names every field
+func ParseColumnMapping(contents []byte) (*ColumnMapping, error) {
+	columns := &ColumnMapping{}
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(columns); err != nil {
+		return nil, fmt.Errorf("invalid column mapping: %w", err)
+	}
+
+	var missing []string
+	for _, field := range columns.fields() {
+		if field.header == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(columns.choices()) == 0 {
+		missing = append(missing, "choices")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("invalid column mapping, no header given for: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+type mappedField struct {
+	name     string
+	header   string
+	value    func(request *algorithm.Request) *string
+	optional bool
+}
+
+// fields lists every field of a request together with the header the mapping names for it
+func (c *ColumnMapping) fields() []mappedField {
+	fields := []mappedField{
+		{"email", c.Email, func(r *algorithm.Request) *string { return &r.Email }, false},
+		{"firstName", c.FirstName, func(r *algorithm.Request) *string { return &r.FirstName }, false},
+		{"lastName", c.LastName, func(r *algorithm.Request) *string { return &r.LastName }, false},
+		{"grade", c.Grade, func(r *algorithm.Request) *string { return &r.Grade }, false},
+	}
+	if c.Timestamp != "" {
+		fields = append(fields, mappedField{"timestamp", c.Timestamp, func(r *algorithm.Request) *string { return &r.Timestamp }, true})
+	}
+	choices := c.choices()
+	for _, slot := range sortedSlots(choices) {
+		slot := slot
+		for i, header := range choices[slot] {
+			i := i
+			fields = append(fields, mappedField{fmt.Sprintf("%s[%d]", strings.ToLower(slot), i), header, func(r *algorithm.Request) *string { return &r.Choices[slot][i] }, false})
+		}
+	}
+	return fields
+}
+
+// choices returns the choice headers of every time slot, with the AM and PM shorthands
+func (c *ColumnMapping) choices() map[string][]string {
+	choices := make(map[string][]string, len(c.Choices)+2)
+	for slot, headers := range c.Choices {
+		if len(headers) > 0 {
+			choices[slot] = headers
+		}
+	}
+	if len(c.AM) > 0 {
+		choices["AM"] = c.AM
+	}
+	if len(c.PM) > 0 {
+		choices["PM"] = c.PM
+	}
+	return choices
+}
+
+func sortedSlots(choices map[string][]string) []string {
+	slots := make([]string, 0, len(choices))
+	for slot := range choices {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+	return slots
+}
+
+// ParseRequests reads the requests CSV, taking every field from the column the mapping names for it
+// and keeping the answers of the other columns as attributes of the request. It fails with the list of
+// mapped columns that are missing from the header row. A nil mapping uses the default headers with the
+// time slots and as many choices per slot as the header row has.
+func ParseRequests(contents []byte, columns *ColumnMapping) ([]*algorithm.Request, error) {
+	reader := csv.NewReader(bytes.NewReader(contents))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty csv file given")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+	if columns == nil {
+		columns = detectChoices(header)
+	}
+	fields := columns.fields()
+	positions := make([]int, len(fields))
+	var missing []string
+	for i, field := range fields {
+		position, ok := index[strings.TrimSpace(field.header)]
+		if !ok {
+			position = -1
+			if !field.optional {
+				missing = append(missing, fmt.Sprintf("%s (%q)", field.name, field.header))
+			}
+		}
+		positions[i] = position
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("required columns not found in the header row: %s", strings.Join(missing, ", "))
+	}
+	mapped := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		mapped[position] = true
+	}
+
+	var requests []*algorithm.Request
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		request := &algorithm.Request{Choices: make(map[string][]string), Line: line}
+		for slot, headers := range columns.choices() {
+			request.Choices[slot] = make([]string, len(headers))
+		}
+		for i, field := range fields {
+			if positions[i] >= 0 && positions[i] < len(record) {
+				*field.value(request) = record[positions[i]]
+			}
+		}
+		for i, value := range record {
+			if !mapped[i] && i < len(header) && strings.TrimSpace(value) != "" {
+				if request.Attributes == nil {
+					request.Attributes = make(map[string]string)
+				}
+				request.Attributes[strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))] = strings.TrimSpace(value)
+			}
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+var choiceHeader = regexp.MustCompile(`^(.+?) Course - (\d+)(?:st|nd|rd|th) Choice\. \(Drop down option\)$`)
+
+// detectChoices returns the default mapping with the choice headers found in the header row, for every
+// time slot they name and in the order of their rank. It returns the default mapping itself when the
+// header row has none.
+func detectChoices(header []string) *ColumnMapping {
+	ranks := make(map[string]map[int]string)
+	for _, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if match := choiceHeader.FindStringSubmatch(name); match != nil {
+			rank, _ := strconv.Atoi(match[2])
+			if ranks[match[1]] == nil {
+				ranks[match[1]] = make(map[int]string)
+			}
+			ranks[match[1]][rank] = name
+		}
+	}
+	if len(ranks) == 0 {
+		return &DefaultColumnMapping
+	}
+
+	columns := DefaultColumnMapping
+	columns.AM, columns.PM = nil, nil
+	columns.Choices = make(map[string][]string, len(ranks))
+	for slot, headers := range ranks {
+		if ranked := rankedHeaders(headers); len(ranked) > 0 {
+			columns.Choices[slot] = ranked
+		}
+	}
+	return &columns
+}
+
+// rankedHeaders orders the choice headers by rank, a gap in the ranks ends the list
+func rankedHeaders(headers map[int]string) []string {
+	var ranked []string
+	for rank := 1; headers[rank] != ""; rank++ {
+		ranked = append(ranked, headers[rank])
+	}
+	return ranked
+}
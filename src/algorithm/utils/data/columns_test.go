@@ -0,0 +1,181 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseColumnMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		err      string
+	}{
+		{
+			name:     "AM and PM shorthands",
+			contents: `{"email": "E", "firstName": "F", "lastName": "L", "grade": "G", "am": ["A1"], "pm": ["P1", "P2"]}`,
+		},
+		{
+			name:     "any time slots",
+			contents: `{"email": "E", "firstName": "F", "lastName": "L", "grade": "G", "choices": {"Monday": ["M1"]}}`,
+		},
+		{
+			name:     "missing fields",
+			contents: `{"email": "E", "grade": "G", "am": ["A1"]}`,
+			err:      "no header given for: firstName, lastName",
+		},
+		{
+			name:     "no choices",
+			contents: `{"email": "E", "firstName": "F", "lastName": "L", "grade": "G", "choices": {"AM": []}}`,
+			err:      "no header given for: choices",
+		},
+		{
+			name:     "unknown field",
+			contents: `{"email": "E", "firstName": "F", "lastName": "L", "grade": "G", "am": ["A1"], "school": "S"}`,
+			err:      "unknown field",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseColumnMapping([]byte(test.contents))
+			if test.err == "" && err != nil {
+				t.Errorf("ParseColumnMapping: %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("ParseColumnMapping() error = %v, want one about %q", err, test.err)
+			}
+		})
+	}
+}
+
+var mapping = &ColumnMapping{
+	Email:     "E-mail",
+	FirstName: "First",
+	LastName:  "Last",
+	Grade:     "Grade",
+	AM:        []string{"Morning 1", "Morning 2"},
+	PM:        []string{"Afternoon"},
+	Timestamp: "Submitted",
+}
+
+func TestParseRequestsThroughAMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		contents   string
+		choices    map[string][]string
+		attributes map[string]string
+		timestamp  string
+		err        string
+	}{
+		{
+			name:      "columns in any order",
+			contents:  "Afternoon,Grade,Morning 2,Last,First,E-mail,Morning 1,Submitted\nZ,Junior,Y,L,F,f@x.org,X,2024-05-01\n",
+			choices:   map[string][]string{"AM": {"X", "Y"}, "PM": {"Z"}},
+			timestamp: "2024-05-01",
+		},
+		{
+			name:     "byte order mark and padded headers",
+			contents: "\ufeffE-mail, First ,Last,Grade,Morning 1,Morning 2,Afternoon\nf@x.org,F,L,Junior,X,,Z\n",
+			choices:  map[string][]string{"AM": {"X", ""}, "PM": {"Z"}},
+		},
+		{
+			name:       "other columns are kept as attributes",
+			contents:   "E-mail,First,Last,Grade,Morning 1,Morning 2,Afternoon,School,Notes\nf@x.org,F,L,Junior,X,Y,Z, North ,\n",
+			choices:    map[string][]string{"AM": {"X", "Y"}, "PM": {"Z"}},
+			attributes: map[string]string{"School": "North"},
+		},
+		{
+			name:     "short rows leave the last choices empty",
+			contents: "E-mail,First,Last,Grade,Afternoon,Morning 1,Morning 2\nf@x.org,F,L,Junior,Z\n",
+			choices:  map[string][]string{"AM": {"", ""}, "PM": {"Z"}},
+		},
+		{
+			name:     "missing columns",
+			contents: "E-mail,First,Grade,Morning 1\nf@x.org,F,Junior,X\n",
+			err:      `lastName ("Last"), am[1] ("Morning 2"), pm[0] ("Afternoon")`,
+		},
+		{name: "empty file", contents: "", err: "empty csv file"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests, err := ParseRequests([]byte(test.contents), mapping)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("ParseRequests() error = %v, want one about %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRequests: %v", err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("read %d requests, want 1", len(requests))
+			}
+			request := requests[0]
+			if request.Email != "f@x.org" || request.FirstName != "F" || request.LastName != "L" || request.Grade != "Junior" || request.Line != 2 {
+				t.Errorf("read %+v, want f@x.org, F L in Junior from line 2", request)
+			}
+			if !reflect.DeepEqual(request.Choices, test.choices) {
+				t.Errorf("Choices = %v, want %v", request.Choices, test.choices)
+			}
+			if !reflect.DeepEqual(request.Attributes, test.attributes) {
+				t.Errorf("Attributes = %v, want %v", request.Attributes, test.attributes)
+			}
+			if request.Timestamp != test.timestamp {
+				t.Errorf("Timestamp = %q, want %q", request.Timestamp, test.timestamp)
+			}
+		})
+	}
+}
+
+func TestParseRequestsDetectsTheChoiceHeaders(t *testing.T) {
+	choice := func(slot, rank string) string {
+		return slot + " Course - " + rank + " Choice. (Drop down option)"
+	}
+	tests := []struct {
+		name    string
+		headers []string
+		values  []string
+		choices map[string][]string
+	}{
+		{
+			name:    "any number of choices in rank order",
+			headers: []string{choice("AM", "3rd"), choice("AM", "1st"), choice("PM", "1st"), choice("AM", "2nd")},
+			values:  []string{"Z", "X", "P", "Y"},
+			choices: map[string][]string{"AM": {"X", "Y", "Z"}, "PM": {"P"}},
+		},
+		{
+			name:    "any time slot",
+			headers: []string{choice("Monday", "1st"), choice("Tuesday", "1st"), choice("Tuesday", "2nd")},
+			values:  []string{"M", "T", "U"},
+			choices: map[string][]string{"Monday": {"M"}, "Tuesday": {"T", "U"}},
+		},
+		{
+			name:    "a gap in the ranks ends the list",
+			headers: []string{choice("AM", "1st"), choice("AM", "2nd"), choice("AM", "4th")},
+			values:  []string{"X", "Y", "W"},
+			choices: map[string][]string{"AM": {"X", "Y"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contents := "Email Address,Students First Name,Students Last Name,Grade in school this year," + strings.Join(test.headers, ",") + "\n" +
+				"f@x.org,F,L,Junior," + strings.Join(test.values, ",") + "\n"
+			requests, err := ParseRequests([]byte(contents), nil)
+			if err != nil {
+				t.Fatalf("ParseRequests: %v", err)
+			}
+			if !reflect.DeepEqual(requests[0].Choices, test.choices) {
+				t.Errorf("Choices = %v, want %v", requests[0].Choices, test.choices)
+			}
+		})
+	}
+}
+
+func TestParseRequestsWithoutChoiceHeadersNeedsTheDefaultColumns(t *testing.T) {
+	_, err := ParseRequests([]byte("Email Address,Students First Name,Students Last Name,Grade in school this year\n"), nil)
+	if err == nil || !strings.Contains(err.Error(), DefaultColumnMapping.AM[0]) {
+		t.Errorf("ParseRequests() error = %v, want the default choice columns missing", err)
+	}
+}
@@ -1,34 +1,244 @@
 package data
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/agavris/june-academy-go/src/algorithm"
 	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
 	"github.com/agavris/june-academy-go/src/imp"
-	"github.com/gocarina/gocsv"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+const (
+	RequestsFile = "jadata.csv"
+	EventsFile   = "events.csv"
+)
+
+// GradePriorities maps the grades of the request form to the priority their students are scheduled with
+// when no grade table is given
+var GradePriorities = map[string]int{
+	"Junior":    1,
+	"Sophomore": 2,
+	"Freshman":  3,
+}
+
+// Options change how the input files are read
+type Options struct {
+	// Columns maps the headers of the requests file to the fields of a request, the default mapping when nil
+	Columns *ColumnMapping
+	// Aliases renames requested courses to the events they stand for
+	Aliases Aliases
+	// Duplicates is the policy for students who submitted the form more than once, latest when empty
+	Duplicates string
+	// Grades maps grades to the tiers their students are scheduled in, the default table when nil
+	Grades *GradeTable
+	// Locks guarantee students seats in courses
+	Locks []Lock
+	// Groups ask for students to be placed together
+	Groups []Group
+	// Apart keeps students out of the same course
+	Apart []KeepApart
+	// ApartPath names the file Apart was read from in messages, ApartFile when empty
+	ApartPath string
+	// Balance limits the composition of every section, no limits when nil
+	Balance *BalanceRules
+	// Slots declares the time slots of the program, a morning and an afternoon when nil
+	Slots *SlotTable
+}
+
+// gradeTable returns the grade table of the options
+func (o Options) gradeTable() *GradeTable {
+	if o.Grades == nil {
+		return &DefaultGradeTable
+	}
+	return o.Grades
+}
+
+// ApartSource returns the name of the file the keep-apart constraints were read from
+func (o Options) ApartSource() string {
+	if o.ApartPath == "" {
+		return ApartFile
+	}
+	return o.ApartPath
+}
+
+// SlotTable returns the slot table of the options
+func (o Options) SlotTable() *SlotTable {
+	if o.Slots == nil {
+		return &DefaultSlotTable
+	}
+	return o.Slots
+}
+
 type DataLoader struct {
+	Options
 	Requests []*algorithm.Request
 	Students []*imp.Student
 	Courses  []*imp.Course
+	Events   []events.Course
+	// Inputs holds the SHA-256 of every input file, keyed by file name
+	Inputs map[string]string
+	// Warnings lists the problems found in the input that did not stop it from loading
+	Warnings []string
+	// UnknownCourses lists the requested courses that are missing from the events file
+	UnknownCourses []string
+	// Duplicates lists the students who submitted the form more than once
+	Duplicates []Duplicate
+	// UnknownGrades lists the grades of the requests that the grade table has no tier for
+	UnknownGrades []string
+	// LockedSeats lists the locks that can be honored, with their course named as in the events
+	LockedSeats []Lock
+	// LockProblems describes the locks that can't be honored
+	LockProblems []string
+	// TogetherGroups lists the group requests of known students, and GroupProblems describes the others
+	TogetherGroups []Group
+	GroupProblems  []string
+	// KeptApart lists the keep-apart constraints that can be met, and ApartProblems describes the others
+	KeptApart     []KeepApart
+	ApartProblems []string
+	// BalanceProblems describes the balance rules that can't be applied
+	BalanceProblems []string
+	// SlotProblems describes the events and choices whose time slot the slot table doesn't declare
+	SlotProblems []string
+
+	// suggestions maps unknown courses to the closest event
+	suggestions map[string]string
 }
 
 func NewDataLoader() *DataLoader {
-	loader := &DataLoader{}
+	return NewDataLoaderWithOptions(Options{})
+}
+
+// NewDataLoaderWithOptions loads the files in the working directory
+func NewDataLoaderWithOptions(options Options) *DataLoader {
+	loader := &DataLoader{Options: options, Inputs: make(map[string]string)}
 	loader.loadData()
 	return loader
 }
 
+// Load parses the contents of a requests and an events file with the same rules as NewDataLoader
+// uses for the files in the working directory. Unlike NewDataLoader it fails when a requested
+// course is missing from the events, since such a dataset can't be scheduled.
+func Load(requests, eventsFile []byte, options Options) (*DataLoader, error) {
+	loader := &DataLoader{Options: options, Inputs: make(map[string]string)}
+	loader.Inputs[RequestsFile] = hash(requests)
+	loader.Inputs[EventsFile] = hash(eventsFile)
+
+	var err error
+	if loader.Requests, err = ParseRequests(requests, options.Columns); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", RequestsFile, err)
+	}
+	if err := loader.parseEvents(eventsFile); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EventsFile, err)
+	}
+	loader.process()
+
+	if err := loader.Check(); err != nil {
+		return nil, err
+	}
+	if len(loader.Requests) == 0 {
+		loader.Warnings = append(loader.Warnings, fmt.Sprintf("%s has no requests", RequestsFile))
+	}
+	return loader, nil
+}
+
 func (d *DataLoader) loadData() {
 	d.loadRequests()
+	d.loadEvents()
+	d.process()
+	for _, warning := range d.Warnings {
+		fmt.Println(warning)
+	}
+}
+
+// process turns the parsed requests and events into students and courses
+func (d *DataLoader) process() {
+	if d.Columns != nil {
+		if contents, err := json.Marshal(d.Columns); err == nil {
+			d.Inputs[ColumnsInput] = hash(contents)
+		}
+	}
+	if len(d.Aliases) > 0 {
+		// Marshalling sorts the keys, so equal tables hash the same
+		if contents, err := json.Marshal(d.Aliases); err == nil {
+			d.Inputs[AliasesFile] = hash(contents)
+		}
+	}
+	if d.Grades != nil && d.Grades != &DefaultGradeTable {
+		if contents, err := json.Marshal(d.Grades); err == nil {
+			d.Inputs[GradesFile] = hash(contents)
+		}
+	}
+	if len(d.Locks) > 0 {
+		if contents, err := json.Marshal(d.Locks); err == nil {
+			d.Inputs[LocksFile] = hash(contents)
+		}
+	}
+	if len(d.Groups) > 0 {
+		if contents, err := json.Marshal(d.Groups); err == nil {
+			d.Inputs[GroupsFile] = hash(contents)
+		}
+	}
+	if len(d.Apart) > 0 {
+		if contents, err := json.Marshal(d.Apart); err == nil {
+			d.Inputs[ApartFile] = hash(contents)
+		}
+	}
+	if d.Balance != nil {
+		if contents, err := json.Marshal(d.Balance); err == nil {
+			d.Inputs[BalanceFile] = hash(contents)
+		}
+	}
+	if d.Slots != nil && d.Slots != &DefaultSlotTable {
+		if contents, err := json.Marshal(d.Slots); err == nil {
+			d.Inputs[SlotsFile] = hash(contents)
+		}
+	}
+	d.SlotTable().canonicalChoices(d.Requests)
+	d.deduplicate()
+	d.reconcileCourses()
+	d.loadLocks()
+	d.loadGroups()
+	d.loadKeepApart()
+	d.BalanceProblems = checkBalance(d.Balance, d.Requests, d.Events)
 	d.loadStudents()
 	d.loadCourses()
 }
 
+// deduplicate keeps one request per student as the duplicate policy says
+func (d *DataLoader) deduplicate() {
+	policy, err := ParseDuplicatePolicy(d.Options.Duplicates)
+	if err != nil {
+		d.Warnings = append(d.Warnings, err.Error())
+		policy = DuplicatesLatest
+	}
+	d.Requests, d.Duplicates = Deduplicate(d.Requests, policy)
+	if policy == DuplicatesReject {
+		return
+	}
+	for _, duplicate := range d.Duplicates {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("%s submitted the form more than once, keeping line %d (%s) and removing lines %s", duplicate.Email, duplicate.Kept, policy, joinLines(duplicate.Removed)))
+	}
+}
+
+func joinLines(lines []int) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = strconv.Itoa(line)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (d *DataLoader) loadRequests() {
-	file, err := os.OpenFile("jadata.csv", os.O_RDWR|os.O_CREATE, os.ModePerm)
+	file, err := os.OpenFile(RequestsFile, os.O_RDWR|os.O_CREATE, os.ModePerm)
 	if err != nil {
 		fmt.Printf("Error opening file: %v\n", err)
 		fmt.Println("Ensure that your file is named jadata.csv and is in the same directory as the executable.")
@@ -43,52 +253,235 @@ func (d *DataLoader) loadRequests() {
 		}
 	}(file)
 
-	if err := gocsv.UnmarshalFile(file, &d.Requests); err != nil {
+	contents, err := io.ReadAll(file)
+	if err == nil {
+		d.Inputs[RequestsFile] = hash(contents)
+		d.Requests, err = ParseRequests(contents, d.Columns)
+	}
+	if err != nil {
 		fmt.Printf("Error reading file: %v\n", err)
 		fmt.Println("Ensure that your file is named jadata.csv and is in the same directory as the executable.")
 		return
 	}
 }
 
-func (d *DataLoader) loadStudents() {
-	converter := make(map[string]int)
-	converter["Freshman"] = 3
-	converter["Sophomore"] = 2
-	converter["Junior"] = 1
+func (d *DataLoader) loadEvents() {
+	contents, err := os.ReadFile(EventsFile)
+	if err == nil {
+		d.Inputs[EventsFile] = hash(contents)
+		err = d.parseEvents(contents)
+	}
+	if err != nil {
+		fmt.Println("Error loading events from CSV file: ", err)
+	}
+}
 
+func (d *DataLoader) parseEvents(contents []byte) error {
+	courses, invalid, err := events.ParseCourses(bytes.NewReader(contents))
+	if err != nil {
+		return err
+	}
+	d.Events = courses
+	for _, recordErr := range invalid {
+		if errors.Is(recordErr, events.ErrUnknownColumn) {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("Ignoring the unknown column %q of %s", recordErr.Column, EventsFile))
+		} else {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("Skipped record of %s: %v", EventsFile, recordErr))
+		}
+	}
+	return nil
+}
+
+// loadLocks keeps the locks that can be honored and describes the others
+func (d *DataLoader) loadLocks() {
+	var problems []lockProblem
+	d.LockedSeats, problems = checkLocks(d.Locks, d.Requests, d.Events, d.SlotTable())
+	for _, problem := range problems {
+		d.LockProblems = append(d.LockProblems, fmt.Sprintf("line %d: %s", problem.line, problem.message))
+	}
+}
+
+// loadGroups keeps the group requests of known students and describes the others
+func (d *DataLoader) loadGroups() {
+	var problems []groupProblem
+	d.TogetherGroups, problems = checkGroups(d.Groups, d.Requests, d.SlotTable())
+	for _, problem := range problems {
+		d.GroupProblems = append(d.GroupProblems, fmt.Sprintf("line %d: %s", problem.line, problem.message))
+	}
+}
+
+// loadKeepApart keeps the keep-apart constraints that can be met and describes the others
+func (d *DataLoader) loadKeepApart() {
+	var problems []apartProblem
+	d.KeptApart, problems = checkKeepApart(d.Apart, d.Requests, d.LockedSeats, d.Events)
+	for _, problem := range problems {
+		d.ApartProblems = append(d.ApartProblems, fmt.Sprintf("line %d: %s", problem.line, problem.message))
+	}
+}
+
+func (d *DataLoader) loadStudents() {
+	table := d.gradeTable()
+	unknown := make(map[string]bool)
 	for _, request := range d.Requests {
-		student := imp.NewStudent(request.FirstName, request.LastName, request.Email, converter[request.Grade], request, request.Grade)
+		tier, ok := table.Tier(request.Grade)
+		if !ok && !unknown[request.Grade] {
+			unknown[request.Grade] = true
+			d.UnknownGrades = append(d.UnknownGrades, request.Grade)
+		} else if ok && !table.Known(request.Grade) {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("Unknown grade %q for %s, the student is scheduled in the default tier %d", request.Grade, request.Email, tier))
+		}
+		student := imp.NewStudent(request.FirstName, request.LastName, request.Email, tier, request, request.Grade)
 		d.Students = append(d.Students, student)
 	}
+	sort.Strings(d.UnknownGrades)
 }
 
 func (d *DataLoader) loadCourses() {
 	courseSet := make(map[string]string)
-	courses, _ := events.ReadCourses("events.csv")
-	coursesToTime := events.MapCoursesToTimeSlots(courses)
+	coursesToTime := events.MapCoursesToTimeSlots(d.Events)
+	unknown := make(map[string]bool)
 	getTimeSlot := func(courseName string, fieldType string) string {
 		if time, ok := coursesToTime[courseName]; ok {
 			return time
 		}
-		fmt.Printf("Course name not found in events map. Please check to make sure the names match in both your events.csv file and your jadata.csv file! Course name: %s, Field type: %s\n", courseName, fieldType)
+		if !unknown[courseName] {
+			unknown[courseName] = true
+			d.UnknownCourses = append(d.UnknownCourses, courseName)
+			d.Warnings = append(d.Warnings, fmt.Sprintf("Course name not found in events map. Please check to make sure the names match in both your events.csv file and your jadata.csv file! Course name: %s, Field type: %s", d.describeUnknownCourse(courseName), fieldType))
+		}
 		return ""
 	}
+	table := d.SlotTable()
+	undeclared := make(map[string]bool)
 	for _, request := range d.Requests {
-		courseFields := map[string]string{
-			"AMFD1": request.AMFD1, "AMFD2": request.AMFD2, "AMFD3": request.AMFD3,
-			"AMFD4": request.AMFD4, "AMFD5": request.AMFD5,
-			"PM1": request.PM1, "PM2": request.PM2, "PM3": request.PM3,
-			"PM4": request.PM4, "PM5": request.PM5,
-		}
-		for fieldType, course := range courseFields {
-			if course != "" {
-				courseSet[course] = getTimeSlot(course, fieldType)
+		for _, slot := range sortedSlots(request.Choices) {
+			if _, ok := table.Slot(slot); !ok {
+				undeclared[slot] = undeclared[slot] || hasChoices(request.Choices[slot])
+				continue
 			}
+			for i, course := range request.Choices[slot] {
+				if course != "" {
+					courseSet[course] = getTimeSlot(course, fmt.Sprintf("%s%d", slot, i+1))
+				}
+			}
+		}
+	}
+	for _, lock := range d.LockedSeats {
+		courseSet[lock.Course] = coursesToTime[lock.Course]
+	}
+	sort.Strings(d.UnknownCourses)
+	for slot, chosen := range undeclared {
+		if !chosen {
+			continue
 		}
+		d.SlotProblems = append(d.SlotProblems, fmt.Sprintf("%s has choices for the time slot %q, expected one of %s", RequestsFile, slot, strings.Join(table.Slots, ", ")))
 	}
+	sort.Strings(d.SlotProblems)
 
+	grades := make(map[string][]string, len(d.Events))
+	for _, event := range d.Events {
+		grades[event.Name] = event.Grades
+	}
+	var unresolved []string
 	for courseName, timeslot := range courseSet {
 		course := imp.NewCourse(courseName, timeslot)
+		if name, slots, ok := table.Resolve(timeslot); ok {
+			course.TimeSlot, course.Slots = name, slots
+		} else if timeslot != "" {
+			unresolved = append(unresolved, fmt.Sprintf("%q takes place in the time slot %q, expected one of %s", courseName, timeslot, table.Names()))
+		}
+		course.Grades = grades[courseName]
 		d.Courses = append(d.Courses, course)
 	}
+	sort.Strings(unresolved)
+	d.SlotProblems = append(d.SlotProblems, unresolved...)
+
+	// Every student should have a course in each time slot that has any
+	var offered []string
+	for _, slot := range table.Slots {
+		for _, course := range d.Courses {
+			if course.Occupies(slot) {
+				offered = append(offered, slot)
+				break
+			}
+		}
+	}
+	for _, student := range d.Students {
+		student.TimeSlots = offered
+	}
+}
+
+// Check fails when the loaded data can't be scheduled: when requested courses are missing from the
+// events, when students submitted the form more than once and the policy rejects duplicates, when
+// students have a grade the grade table has no tier for, when locked seats, group requests,
+// keep-apart constraints or balance rules can't be honored, or when events or choices have a time slot
+// the slot table doesn't declare
+func (d *DataLoader) Check() error {
+	var errs []error
+	if len(d.UnknownCourses) > 0 {
+		unknown := make([]string, len(d.UnknownCourses))
+		for i, courseName := range d.UnknownCourses {
+			unknown[i] = d.describeUnknownCourse(courseName)
+		}
+		errs = append(errs, fmt.Errorf("requested courses missing from %s: %s\nRun the reconcile command to match them to events", EventsFile, strings.Join(unknown, ", ")))
+	}
+	if d.Duplicates != nil && d.Options.Duplicates == DuplicatesReject {
+		emails := make([]string, len(d.Duplicates))
+		for i, duplicate := range d.Duplicates {
+			emails[i] = fmt.Sprintf("%s (lines %s)", duplicate.Email, joinLines(duplicate.Removed))
+		}
+		errs = append(errs, fmt.Errorf("students submitted %s more than once: %s", RequestsFile, strings.Join(emails, ", ")))
+	}
+	if len(d.UnknownGrades) > 0 {
+		grades := make([]string, len(d.UnknownGrades))
+		for i, grade := range d.UnknownGrades {
+			grades[i] = fmt.Sprintf("%q", grade)
+		}
+		errs = append(errs, fmt.Errorf("grades without a tier in the grade table: %s\nAdd them to %s or give it a default tier", strings.Join(grades, ", "), GradesFile))
+	}
+	if len(d.LockProblems) > 0 {
+		errs = append(errs, fmt.Errorf("locked seats of %s that can't be honored:\n%s", LocksFile, strings.Join(d.LockProblems, "\n")))
+	}
+	if len(d.GroupProblems) > 0 {
+		errs = append(errs, fmt.Errorf("group requests of %s that can't be honored:\n%s", GroupsFile, strings.Join(d.GroupProblems, "\n")))
+	}
+	if len(d.ApartProblems) > 0 {
+		errs = append(errs, fmt.Errorf("keep-apart constraints of %s that can't be met:\n%s", d.ApartSource(), strings.Join(d.ApartProblems, "\n")))
+	}
+	if len(d.BalanceProblems) > 0 {
+		errs = append(errs, fmt.Errorf("balance rules of %s that can't be applied:\n%s", BalanceFile, strings.Join(d.BalanceProblems, "\n")))
+	}
+	if len(d.SlotProblems) > 0 {
+		errs = append(errs, fmt.Errorf("time slots missing from %s:\n%s", SlotsFile, strings.Join(d.SlotProblems, "\n")))
+	}
+	return errors.Join(errs...)
+}
+
+// Choices returns the largest number of ranked choices any request has in a time slot
+func (d *DataLoader) Choices() int {
+	choices := 0
+	for _, request := range d.Requests {
+		for _, courses := range request.Choices {
+			if len(courses) > choices {
+				choices = len(courses)
+			}
+		}
+	}
+	return choices
+}
+
+// CourseEvents returns the rows of the events file for a course, which together describe its sections
+func (d *DataLoader) CourseEvents(courseName string) []events.Course {
+	var rows []events.Course
+	for _, course := range d.Events {
+		if course.Name == courseName {
+			rows = append(rows, course)
+		}
+	}
+	return rows
+}
+
+func hash(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,147 @@
+package data
+
+import (
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The policies for students who submitted the request form more than once
+const (
+	// DuplicatesLatest keeps the latest submission by timestamp, or by line when there are no timestamps
+	DuplicatesLatest = "latest"
+	// DuplicatesFirst keeps the first submission in the file
+	DuplicatesFirst = "first"
+	// DuplicatesReject refuses to schedule the requests until the duplicates are removed
+	DuplicatesReject = "reject"
+)
+
+// ParseDuplicatePolicy checks a policy name, the empty name is the latest policy
+func ParseDuplicatePolicy(name string) (string, error) {
+	switch name {
+	case "":
+		return DuplicatesLatest, nil
+	case DuplicatesLatest, DuplicatesFirst, DuplicatesReject:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy %q, expected %s, %s or %s", name, DuplicatesLatest, DuplicatesFirst, DuplicatesReject)
+}
+
+// Duplicate lists the lines of the requests of a student who submitted the form more than once.
+// Kept is zero when the policy rejects duplicates.
+type Duplicate struct {
+	Email   string `json:"email"`
+	Kept    int    `json:"keptLine,omitempty"`
+	Removed []int  `json:"removedLines"`
+}
+
+// NormalizeEmail folds the case and surrounding whitespace of an email address
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Deduplicate keeps one request per normalized email according to the policy and returns the kept
+// requests in their original order together with the duplicates it found. The reject policy keeps
+// every request. Requests without an email are always kept.
+func Deduplicate(requests []*algorithm.Request, policy string) ([]*algorithm.Request, []Duplicate) {
+	byEmail := make(map[string][]*algorithm.Request)
+	var emails []string
+	for _, request := range requests {
+		email := NormalizeEmail(request.Email)
+		if email == "" {
+			continue
+		}
+		if _, ok := byEmail[email]; !ok {
+			emails = append(emails, email)
+		}
+		byEmail[email] = append(byEmail[email], request)
+	}
+
+	removed := make(map[*algorithm.Request]bool)
+	var duplicates []Duplicate
+	for _, email := range emails {
+		group := byEmail[email]
+		if len(group) < 2 {
+			continue
+		}
+		duplicate := Duplicate{Email: email}
+		var kept *algorithm.Request
+		switch policy {
+		case DuplicatesFirst:
+			kept = group[0]
+		case DuplicatesReject:
+		default:
+			kept = latestSubmission(group)
+		}
+		for _, request := range group {
+			if request == kept {
+				duplicate.Kept = request.Line
+				continue
+			}
+			duplicate.Removed = append(duplicate.Removed, request.Line)
+			if kept != nil {
+				removed[request] = true
+			}
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	kept := make([]*algorithm.Request, 0, len(requests)-len(removed))
+	for _, request := range requests {
+		if !removed[request] {
+			kept = append(kept, request)
+		}
+	}
+	return kept, duplicates
+}
+
+// latestSubmission returns the request with the latest timestamp, or the last one in the file
+// when any of them has no timestamp that can be read
+func latestSubmission(group []*algorithm.Request) *algorithm.Request {
+	latest := group[len(group)-1]
+	var latestTime time.Time
+	for _, request := range group {
+		submitted, ok := parseTimestamp(request.Timestamp)
+		if !ok {
+			return group[len(group)-1]
+		}
+		if latestTime.IsZero() || !submitted.Before(latestTime) {
+			latest, latestTime = request, submitted
+		}
+	}
+	return latest
+}
+
+var timestampLayouts = []string{
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+	"2006/01/02 3:04:05 PM",
+	"2006/01/02 15:04:05",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+var timestampZone = regexp.MustCompile(`^(.*?)\s*GMT([+-]\d{1,2})(?::?(\d{2}))?$`)
+
+// parseTimestamp reads the timestamps form tools export, including the "GMT-4" zones of Google Forms
+func parseTimestamp(timestamp string) (time.Time, bool) {
+	timestamp = strings.TrimSpace(timestamp)
+	offset := 0
+	if match := timestampZone.FindStringSubmatch(timestamp); match != nil {
+		hours, _ := strconv.Atoi(match[2])
+		minutes, _ := strconv.Atoi(match[3])
+		if hours < 0 {
+			minutes = -minutes
+		}
+		timestamp, offset = match[1], hours*3600+minutes*60
+	}
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.ParseInLocation(layout, timestamp, time.FixedZone("", offset)); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm"
+)
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"", DuplicatesLatest, true},
+		{DuplicatesLatest, DuplicatesLatest, true},
+		{DuplicatesFirst, DuplicatesFirst, true},
+		{DuplicatesReject, DuplicatesReject, true},
+		{"Latest", "", false},
+		{"last", "", false},
+	}
+	for _, test := range tests {
+		policy, err := ParseDuplicatePolicy(test.name)
+		if policy != test.want || (err == nil) != test.ok {
+			t.Errorf("ParseDuplicatePolicy(%q) = %q, %v, want %q", test.name, policy, err, test.want)
+		}
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	// The student submitted the form on lines 2, 3 and 5, the one on line 3 is the most recent
+	requests := []*algorithm.Request{
+		{Email: "a@x.org", Line: 2, Timestamp: "5/1/2024 9:00:00"},
+		{Email: " A@X.org ", Line: 3, Timestamp: "5/2/2024 9:00:00"},
+		{Email: "b@x.org", Line: 4, Timestamp: "5/1/2024 10:00:00"},
+		{Email: "a@x.org", Line: 5, Timestamp: "5/1/2024 12:00:00"},
+		{Email: "", Line: 6},
+		{Email: "", Line: 7},
+	}
+	tests := []struct {
+		policy     string
+		timestamps bool
+		kept       []int
+		duplicate  Duplicate
+	}{
+		{DuplicatesLatest, true, []int{3, 4, 6, 7}, Duplicate{Email: "a@x.org", Kept: 3, Removed: []int{2, 5}}},
+		{DuplicatesLatest, false, []int{4, 5, 6, 7}, Duplicate{Email: "a@x.org", Kept: 5, Removed: []int{2, 3}}},
+		{DuplicatesFirst, true, []int{2, 4, 6, 7}, Duplicate{Email: "a@x.org", Kept: 2, Removed: []int{3, 5}}},
+		{DuplicatesReject, true, []int{2, 3, 4, 5, 6, 7}, Duplicate{Email: "a@x.org", Removed: []int{2, 3, 5}}},
+	}
+	for _, test := range tests {
+		input := make([]*algorithm.Request, len(requests))
+		for i, request := range requests {
+			copied := *request
+			if !test.timestamps {
+				copied.Timestamp = ""
+			}
+			input[i] = &copied
+		}
+		kept, duplicates := Deduplicate(input, test.policy)
+		var lines []int
+		for _, request := range kept {
+			lines = append(lines, request.Line)
+		}
+		if !reflect.DeepEqual(lines, test.kept) {
+			t.Errorf("%s with timestamps %v kept lines %v, want %v", test.policy, test.timestamps, lines, test.kept)
+		}
+		if !reflect.DeepEqual(duplicates, []Duplicate{test.duplicate}) {
+			t.Errorf("%s with timestamps %v found %+v, want %+v", test.policy, test.timestamps, duplicates, test.duplicate)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		want      string
+	}{
+		{"5/1/2024 9:05:00", "2024-05-01T09:05:00Z"},
+		{"2024/05/01 9:05:00 PM GMT-4", "2024-05-01T21:05:00-04:00"},
+		{"2024/05/01 9:05:00 AM GMT+5:30", "2024-05-01T09:05:00+05:30"},
+		{"2024-05-01T09:05:00+02:00", "2024-05-01T09:05:00+02:00"},
+		{"yesterday", ""},
+	}
+	for _, test := range tests {
+		parsed, ok := parseTimestamp(test.timestamp)
+		if test.want == "" {
+			if ok {
+				t.Errorf("parseTimestamp(%q) = %v, want it unreadable", test.timestamp, parsed)
+			}
+			continue
+		}
+		if !ok || parsed.Format("2006-01-02T15:04:05Z07:00") != test.want {
+			t.Errorf("parseTimestamp(%q) = %v, %v, want %s", test.timestamp, parsed, ok, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GradesFile is the grade table that is used when it exists in the working directory
+const GradesFile = "grades.json"
+
+// GradeTable maps the grades of the request form to the tier their students are scheduled in. Lower
+// tiers are scheduled first, and any number of tiers may be used. Grades are matched regardless of case.
+type GradeTable struct {
+	Tiers map[string]int `json:"tiers"`
+	// Default is the tier of the grades missing from Tiers. When it is nil those grades are an error.
+	Default *int `json:"default,omitempty"`
+}
+
+// DefaultGradeTable schedules juniors first and freshmen last, and rejects every other grade
+var DefaultGradeTable = GradeTable{Tiers: GradePriorities}
+
+// ReadGradeTable loads a grade table from a JSON file. A missing file is the default table.
+func ReadGradeTable(path string) (*GradeTable, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &DefaultGradeTable, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseGradeTable(contents)
+}
+
+func ParseGradeTable(contents []byte) (*GradeTable, error) {
+	table := &GradeTable{}
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(table); err != nil {
+		return nil, fmt.Errorf("invalid grade table: %w", err)
+	}
+	if len(table.Tiers) == 0 && table.Default == nil {
+		return nil, errors.New("invalid grade table: it has no tiers and no default tier")
+	}
+	seen := make(map[string]string)
+	for grade := range table.Tiers {
+		folded := foldGrade(grade)
+		if folded == "" {
+			return nil, errors.New("invalid grade table: a grade is blank")
+		}
+		if other, ok := seen[folded]; ok {
+			return nil, fmt.Errorf("invalid grade table: %q and %q are the same grade", other, grade)
+		}
+		seen[folded] = grade
+	}
+	return table, nil
+}
+
+// Tier returns the tier of a grade, and false when the grade is unknown and there is no default tier
+func (t *GradeTable) Tier(grade string) (int, bool) {
+	folded := foldGrade(grade)
+	for name, tier := range t.Tiers {
+		if foldGrade(name) == folded {
+			return tier, true
+		}
+	}
+	if t.Default != nil {
+		return *t.Default, true
+	}
+	return 0, false
+}
+
+// Known reports whether the table lists the grade, rather than placing it in the default tier
+func (t *GradeTable) Known(grade string) bool {
+	for name := range t.Tiers {
+		if foldGrade(name) == foldGrade(grade) {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the grades of the table ordered by tier, then by name
+func (t *GradeTable) Names() []string {
+	names := make([]string, 0, len(t.Tiers))
+	for name := range t.Tiers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if t.Tiers[names[i]] != t.Tiers[names[j]] {
+			return t.Tiers[names[i]] < t.Tiers[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func foldGrade(grade string) string {
+	return strings.ToLower(strings.TrimSpace(grade))
+}
@@ -0,0 +1,59 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGradeTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		// tiers maps grades to the tier they should be scheduled in, -1 for the unknown ones
+		tiers map[string]int
+		err   string
+	}{
+		{
+			name:     "tiers regardless of case",
+			contents: `{"tiers": {"Senior": 0, "junior": 1}}`,
+			tiers:    map[string]int{"senior": 0, " JUNIOR ": 1, "Freshman": -1},
+		},
+		{
+			name:     "default tier for the other grades",
+			contents: `{"tiers": {"Senior": 0}, "default": 3}`,
+			tiers:    map[string]int{"Senior": 0, "Freshman": 3, "": 3},
+		},
+		{
+			name:     "only a default tier",
+			contents: `{"default": 2}`,
+			tiers:    map[string]int{"Junior": 2},
+		},
+		{name: "no tiers", contents: `{"tiers": {}}`, err: "no tiers and no default tier"},
+		{name: "blank grade", contents: `{"tiers": {" ": 0}}`, err: "a grade is blank"},
+		{name: "same grade twice", contents: `{"tiers": {"Junior": 0, "JUNIOR": 1}}`, err: "are the same grade"},
+		{name: "unknown field", contents: `{"tiers": {"Junior": 0}, "order": []}`, err: "unknown field"},
+		{name: "not JSON", contents: `Junior,0`, err: "invalid grade table"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table, err := ParseGradeTable([]byte(test.contents))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("ParseGradeTable() error = %v, want one about %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGradeTable: %v", err)
+			}
+			for grade, want := range test.tiers {
+				tier, ok := table.Tier(grade)
+				if want < 0 && ok {
+					t.Errorf("Tier(%q) = %d, want the grade unknown", grade, tier)
+				} else if want >= 0 && (!ok || tier != want) {
+					t.Errorf("Tier(%q) = %d, %v, want %d", grade, tier, ok, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// GroupsFile is the table of students who asked to be placed together that is used when it exists in
+// the working directory
+const GroupsFile = "groups.csv"
+
+// Group asks for its students to share a section in a time slot. TimeSlot is a slot or a span of the slot
+// table, or empty when the students want to be together in every slot.
+type Group struct {
+	Emails   []string `json:"emails"`
+	TimeSlot string   `json:"timeSlot,omitempty"`
+	// Slots lists the slots the students want to share a section in, as the slot table resolves TimeSlot
+	Slots []string `json:"slots,omitempty"`
+	// Line is the line of the groups file the group was read from
+	Line int `json:"line"`
+}
+
+// ReadGroups loads the group requests from a CSV file. A missing file has no group requests.
+func ReadGroups(path string) ([]Group, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseGroups(contents)
+}
+
+// ParseGroups reads group requests from a CSV file with a header row. Every column whose name starts
+// with "email" holds a student of the group, so a row names a pair or a larger group, and an optional
+// "time slot" column names the slot the group applies to.
+func ParseGroups(contents []byte) ([]Group, error) {
+	reader := csv.NewReader(bytes.NewReader(contents))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid group requests: %w", err)
+	}
+
+	var emails []int
+	timeSlot := -1
+	for i, name := range header {
+		name = strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(name, "\ufeff")), " "))
+		switch {
+		case name == "time slot" || name == "slot":
+			timeSlot = i
+		case strings.HasPrefix(name, "email"):
+			emails = append(emails, i)
+		}
+	}
+	if len(emails) < 2 {
+		return nil, errors.New("invalid group requests: the header row needs at least two email columns")
+	}
+
+	var groups []Group
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid group requests: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		group := Group{Line: line}
+		for _, i := range emails {
+			if email := field(i); email != "" {
+				group.Emails = append(group.Emails, email)
+			}
+		}
+		if len(group.Emails) == 0 {
+			continue
+		}
+		group.TimeSlot = field(timeSlot)
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// groupProblem is a group request that can't be considered
+type groupProblem struct {
+	line    int
+	message string
+}
+
+// checkGroups matches the group requests to the students of the requests and their time slot to the slot
+// table, and returns the groups that can be considered together with the problems of the others. Groups
+// fail when a student or the time slot is unknown, or when fewer than two different students are left.
+// The time slot of a group names a slot or a span of the table, never its default, so a misspelled slot
+// is reported instead of applying to the slots of the default.
+func checkGroups(groups []Group, requests []*algorithm.Request, table *SlotTable) ([]Group, []groupProblem) {
+	students := make(map[string]bool, len(requests))
+	for _, request := range requests {
+		students[NormalizeEmail(request.Email)] = true
+	}
+
+	var valid []Group
+	var problems []groupProblem
+	for _, group := range groups {
+		fail := func(format string, args ...interface{}) {
+			problems = append(problems, groupProblem{line: group.Line, message: fmt.Sprintf(format, args...)})
+		}
+		if group.TimeSlot == "" {
+			group.Slots = table.Slots
+		} else {
+			name, slots, ok := table.lookup(group.TimeSlot)
+			if !ok {
+				fail("invalid time slot %q, use one of %s or leave it empty for every slot", group.TimeSlot, table.Names())
+				continue
+			}
+			group.TimeSlot, group.Slots = name, slots
+		}
+		seen := make(map[string]bool)
+		var emails []string
+		unknown := false
+		for _, email := range group.Emails {
+			normalized := NormalizeEmail(email)
+			if !students[normalized] {
+				fail("no student with the email %q is in %s", email, RequestsFile)
+				unknown = true
+				break
+			}
+			if !seen[normalized] {
+				seen[normalized] = true
+				emails = append(emails, email)
+			}
+		}
+		if unknown {
+			continue
+		}
+		if len(emails) < 2 {
+			fail("a group needs at least two different students")
+			continue
+		}
+		group.Emails = emails
+		valid = append(valid, group)
+	}
+	return valid, problems
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm"
+)
+
+func TestParseGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		groups []Group
+		ok     bool
+	}{
+		{"empty", "", nil, true},
+		{
+			name: "pairs and larger groups",
+			file: "\ufeffEmail 1,Email 2,Email 3,Time Slot\n" +
+				"a@x.org, b@x.org,,AM\n" +
+				",,\n" +
+				"a@x.org,c@x.org,d@x.org\n",
+			groups: []Group{
+				{Emails: []string{"a@x.org", "b@x.org"}, TimeSlot: "AM", Line: 2},
+				{Emails: []string{"a@x.org", "c@x.org", "d@x.org"}, Line: 4},
+			},
+			ok: true,
+		},
+		{"one email column", "Email,Time Slot\na@x.org,AM\n", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			groups, err := ParseGroups([]byte(test.file))
+			if (err == nil) != test.ok {
+				t.Fatalf("ParseGroups() error = %v, want ok %v", err, test.ok)
+			}
+			if !reflect.DeepEqual(groups, test.groups) {
+				t.Errorf("ParseGroups() = %+v, want %+v", groups, test.groups)
+			}
+		})
+	}
+}
+
+func TestCheckGroups(t *testing.T) {
+	requests := []*algorithm.Request{{Email: "a@x.org"}, {Email: "B@x.org"}, {Email: "c@x.org"}}
+	table := &SlotTable{
+		Slots:   []string{"P1", "P2", "P3"},
+		Spans:   map[string][]string{"Morning": {"P1", "P2"}},
+		Default: []string{"P1", "P2", "P3"},
+	}
+	tests := []struct {
+		name     string
+		group    Group
+		timeSlot string
+		slots    []string
+		// problem is part of the message of the problem when the group can't be considered
+		problem string
+	}{
+		{"every slot", Group{Emails: []string{"a@x.org", "b@x.org"}}, "", []string{"P1", "P2", "P3"}, ""},
+		{"slot in another case", Group{Emails: []string{"a@x.org", "c@x.org"}, TimeSlot: "p2"}, "P2", []string{"P2"}, ""},
+		{"span", Group{Emails: []string{"a@x.org", "c@x.org"}, TimeSlot: "MORNING"}, "Morning", []string{"P1", "P2"}, ""},
+		{"unknown slot despite the default", Group{Emails: []string{"a@x.org", "c@x.org"}, TimeSlot: "P4"}, "", nil, `invalid time slot "P4"`},
+		{"unknown student", Group{Emails: []string{"a@x.org", "z@x.org"}}, "", nil, `"z@x.org"`},
+		{"same student twice", Group{Emails: []string{"a@x.org", " A@x.org"}}, "", nil, "at least two different students"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.group.Line = 2
+			valid, problems := checkGroups([]Group{test.group}, requests, table)
+			if test.problem != "" {
+				if len(valid) != 0 || len(problems) != 1 || problems[0].line != 2 || !strings.Contains(problems[0].message, test.problem) {
+					t.Errorf("checkGroups() = %+v, %+v, want the problem %q on line 2", valid, problems, test.problem)
+				}
+				return
+			}
+			if len(problems) != 0 || len(valid) != 1 {
+				t.Fatalf("checkGroups() = %+v, %+v, want the group considered", valid, problems)
+			}
+			if valid[0].TimeSlot != test.timeSlot || !reflect.DeepEqual(valid[0].Slots, test.slots) {
+				t.Errorf("the group is in %q taking up %v, want %q taking up %v", valid[0].TimeSlot, valid[0].Slots, test.timeSlot, test.slots)
+			}
+		})
+	}
+}
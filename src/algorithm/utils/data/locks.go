@@ -0,0 +1,208 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LocksFile is the table of locked seats that is used when it exists in the working directory
+const LocksFile = "locked.csv"
+
+// Lock guarantees a student a seat in a course, whatever they requested. Section names the section
+// of the course, counted from one, or is zero when any section will do.
+type Lock struct {
+	Email   string `json:"email"`
+	Course  string `json:"course"`
+	Section int    `json:"section,omitempty"`
+	// Line is the line of the locks file the lock was read from
+	Line int `json:"line"`
+}
+
+// ReadLocks loads the locked seats from a CSV file. A missing file has no locked seats.
+func ReadLocks(path string) ([]Lock, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseLocks(contents)
+}
+
+// ParseLocks reads locked seats from a CSV file with a header row naming an email and a course column,
+// and optionally a section column. Every row locks one seat, so a student locked into courses of two
+// time slots has two rows.
+func ParseLocks(contents []byte) ([]Lock, error) {
+	reader := csv.NewReader(bytes.NewReader(contents))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid locked seats: %w", err)
+	}
+
+	email, course, section := -1, -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(name, "\ufeff")), " ")) {
+		case "email", "email address":
+			email = i
+		case "course", "course name", "event":
+			course = i
+		case "section":
+			section = i
+		}
+	}
+	if email < 0 || course < 0 {
+		return nil, errors.New("invalid locked seats: the header row needs an email and a course column")
+	}
+
+	var locks []Lock
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid locked seats: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		lock := Lock{Email: field(email), Course: field(course), Line: line}
+		if lock.Email == "" && lock.Course == "" {
+			continue
+		}
+		if value := field(section); value != "" {
+			if lock.Section, err = strconv.Atoi(value); err != nil || lock.Section < 1 {
+				return nil, fmt.Errorf("invalid locked seats: line %d: invalid section %q", line, value)
+			}
+		}
+		locks = append(locks, lock)
+	}
+	return locks, nil
+}
+
+// lockProblem is a lock that can't be honored
+type lockProblem struct {
+	line    int
+	message string
+}
+
+// checkLocks matches the locks to the students of the requests and to the events, and returns the locks
+// that can be honored, with the course named as in the events, together with the problems of the others.
+// Locks fail when the student or course is unknown, when they take up a time slot of another lock of the
+// student, or when more students are locked into a course or section than it seats.
+func checkLocks(locks []Lock, requests []*algorithm.Request, courses []events.Course, table *SlotTable) ([]Lock, []lockProblem) {
+	students := make(map[string]bool, len(requests))
+	for _, request := range requests {
+		students[NormalizeEmail(request.Email)] = true
+	}
+	rows := make(map[string][]events.Course)
+	byNormalized := make(map[string]string)
+	for _, course := range courses {
+		rows[course.Name] = append(rows[course.Name], course)
+		byNormalized[NormalizeCourseName(course.Name)] = course.Name
+	}
+
+	var valid []Lock
+	var problems []lockProblem
+	slots := make(map[string]map[string]Lock)
+	locked := make(map[string]int)
+	lockedSections := make(map[string]map[int]int)
+	for _, lock := range locks {
+		fail := func(format string, args ...interface{}) {
+			problems = append(problems, lockProblem{line: lock.Line, message: fmt.Sprintf(format, args...)})
+		}
+		email := NormalizeEmail(lock.Email)
+		if !students[email] {
+			fail("no student with the email %q is in %s", lock.Email, RequestsFile)
+			continue
+		}
+		if _, ok := rows[lock.Course]; !ok {
+			name, ok := byNormalized[NormalizeCourseName(lock.Course)]
+			if !ok {
+				fail("course %q is not in %s", lock.Course, EventsFile)
+				continue
+			}
+			lock.Course = name
+		}
+		capacities := sectionCapacities(rows[lock.Course])
+		if lock.Section > len(capacities) {
+			fail("%q has %d sections, there is no section %d", lock.Course, len(capacities), lock.Section)
+			continue
+		}
+
+		timeSlot := rows[lock.Course][0].TimeSlot
+		_, taken, ok := table.Resolve(timeSlot)
+		if !ok {
+			taken = []string{timeSlot}
+		}
+		if slots[email] == nil {
+			slots[email] = make(map[string]Lock)
+		}
+		overlap := false
+		for _, slot := range taken {
+			if other, ok := slots[email][slot]; ok {
+				fail("%s is already locked into %q on line %d at the same time", lock.Email, other.Course, other.Line)
+				overlap = true
+				break
+			}
+		}
+		if overlap {
+			continue
+		}
+
+		total := 0
+		for _, capacity := range capacities {
+			total += capacity
+		}
+		if locked[lock.Course] >= total {
+			fail("more students are locked into %q than its %d seats", lock.Course, total)
+			continue
+		}
+		if lock.Section > 0 {
+			if lockedSections[lock.Course] == nil {
+				lockedSections[lock.Course] = make(map[int]int)
+			}
+			if lockedSections[lock.Course][lock.Section] >= capacities[lock.Section-1] {
+				fail("more students are locked into section %d of %q than its %d seats", lock.Section, lock.Course, capacities[lock.Section-1])
+				continue
+			}
+			lockedSections[lock.Course][lock.Section]++
+		}
+		locked[lock.Course]++
+		for _, slot := range taken {
+			slots[email][slot] = lock
+		}
+		valid = append(valid, lock)
+	}
+	return valid, problems
+}
+
+// sectionCapacities lists the capacity of every section of a course, in the order the sections are numbered
+func sectionCapacities(rows []events.Course) []int {
+	var capacities []int
+	for _, row := range rows {
+		for i := 0; i < row.Sections; i++ {
+			capacities = append(capacities, row.MaxStudents)
+		}
+	}
+	return capacities
+}
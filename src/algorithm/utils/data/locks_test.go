@@ -0,0 +1,117 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
+)
+
+func TestParseLocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		locks []Lock
+		ok    bool
+	}{
+		{"empty", "", nil, true},
+		{
+			name: "columns under their aliases",
+			file: "Section,Course Name,Email Address\n,Robotics,a@x.org\n2,Chess, b@x.org\n,,\n",
+			locks: []Lock{
+				{Email: "a@x.org", Course: "Robotics", Line: 2},
+				{Email: "b@x.org", Course: "Chess", Section: 2, Line: 3},
+			},
+			ok: true,
+		},
+		{"missing course column", "Email,Room\na@x.org,Lab\n", nil, false},
+		{"invalid section", "Email,Course,Section\na@x.org,Robotics,0\n", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			locks, err := ParseLocks([]byte(test.file))
+			if (err == nil) != test.ok {
+				t.Fatalf("ParseLocks() error = %v, want ok %v", err, test.ok)
+			}
+			if !reflect.DeepEqual(locks, test.locks) {
+				t.Errorf("ParseLocks() = %+v, want %+v", locks, test.locks)
+			}
+		})
+	}
+}
+
+func TestCheckLocks(t *testing.T) {
+	requests := []*algorithm.Request{{Email: "a@x.org"}, {Email: "b@x.org"}, {Email: "c@x.org"}}
+	courses := []events.Course{
+		{Name: "Robotics", MaxStudents: 1, TimeSlot: "AM", Sections: 2},
+		{Name: "Chess", MaxStudents: 1, TimeSlot: "PM", Sections: 1},
+		{Name: "Pottery", MaxStudents: 5, TimeSlot: "FullDay", Sections: 1},
+	}
+	tests := []struct {
+		name  string
+		locks []Lock
+		// problems holds part of the message of the problem of every lock in order, empty for locks that hold
+		problems []string
+	}{
+		{
+			name:     "seats within the capacity",
+			locks:    []Lock{{Email: "a@x.org", Course: "robotics "}, {Email: "B@x.org", Course: "Robotics", Section: 2}, {Email: "a@x.org", Course: "Chess"}},
+			problems: []string{"", "", ""},
+		},
+		{
+			name:     "unknown student and course",
+			locks:    []Lock{{Email: "z@x.org", Course: "Chess"}, {Email: "a@x.org", Course: "Knitting"}},
+			problems: []string{`"z@x.org"`, `"Knitting" is not in`},
+		},
+		{
+			name:     "no such section",
+			locks:    []Lock{{Email: "a@x.org", Course: "Chess", Section: 2}},
+			problems: []string{"there is no section 2"},
+		},
+		{
+			name:     "overlapping time slots",
+			locks:    []Lock{{Email: "a@x.org", Course: "Chess"}, {Email: "a@x.org", Course: "Pottery"}},
+			problems: []string{"", `already locked into "Chess"`},
+		},
+		{
+			name: "more seats than the course has",
+			locks: []Lock{
+				{Email: "a@x.org", Course: "Robotics", Section: 1}, {Email: "b@x.org", Course: "Robotics", Section: 1},
+				{Email: "b@x.org", Course: "Robotics"}, {Email: "c@x.org", Course: "Robotics"},
+			},
+			problems: []string{"", "section 1 of \"Robotics\" than its 1 seats", "", "\"Robotics\" than its 2 seats"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := range test.locks {
+				test.locks[i].Line = i + 2
+			}
+			valid, problems := checkLocks(test.locks, requests, courses, &DefaultSlotTable)
+			byLine := make(map[int]string)
+			for _, problem := range problems {
+				byLine[problem.line] = problem.message
+			}
+			held := 0
+			for i, want := range test.problems {
+				got := byLine[i+2]
+				if want == "" && got != "" || want != "" && !strings.Contains(got, want) {
+					t.Errorf("lock %d has the problem %q, want %q", i+1, got, want)
+				}
+				if want == "" {
+					held++
+				}
+			}
+			if len(valid) != held {
+				t.Errorf("%d locks hold, want %d", len(valid), held)
+			}
+			for _, lock := range valid {
+				if lock.Course != "Robotics" && lock.Course != "Chess" && lock.Course != "Pottery" {
+					t.Errorf("a lock holds for %q, want the course named as in the events", lock.Course)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm"
+	"golang.org/x/text/unicode/norm"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AliasesFile is the alias table that is used when it exists in the working directory
+const AliasesFile = "aliases.json"
+
+// Aliases maps course names as they are written in the requests to the course names of the events file
+type Aliases map[string]string
+
+// ReadAliases loads an alias table from a JSON file. A missing file is an empty table.
+func ReadAliases(path string) (Aliases, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Aliases{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseAliases(contents)
+}
+
+func ParseAliases(contents []byte) (Aliases, error) {
+	aliases := Aliases{}
+	if err := json.Unmarshal(contents, &aliases); err != nil {
+		return nil, fmt.Errorf("invalid alias table: %w", err)
+	}
+	return aliases, nil
+}
+
+// Write saves the alias table as JSON
+func (a Aliases) Write(path string) error {
+	contents, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(contents, '\n'), 0644)
+}
+
+var punctuationFolds = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "―", "-",
+)
+
+// NormalizeCourseName folds the differences form tools introduce into course names: Unicode
+// compatibility forms, curly quotes and dashes, repeated or surrounding whitespace and case
+func NormalizeCourseName(name string) string {
+	name = norm.NFKC.String(name)
+	name = punctuationFolds.Replace(name)
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+const (
+	MatchAlias      = "alias"
+	MatchNormalized = "normalized"
+	MatchFuzzy      = "fuzzy"
+	MatchNone       = "none"
+)
+
+// Match pairs a requested course name that is missing from the events with the event it most likely
+// means. Alias and normalized matches are applied when loading, fuzzy ones are only suggested.
+type Match struct {
+	Requested string `json:"requested"`
+	Event     string `json:"event,omitempty"`
+	Method    string `json:"method"`
+	Distance  int    `json:"distance,omitempty"`
+	Students  int    `json:"students"`
+}
+
+// Reconcile proposes an event for every requested course name that is not an event name, in the
+// order of the names
+func Reconcile(requests []*algorithm.Request, eventNames []string, aliases Aliases) []Match {
+	isEvent := make(map[string]bool, len(eventNames))
+	byNormalized := make(map[string]string, len(eventNames))
+	for _, name := range eventNames {
+		isEvent[name] = true
+		if _, ok := byNormalized[NormalizeCourseName(name)]; !ok {
+			byNormalized[NormalizeCourseName(name)] = name
+		}
+	}
+	aliasesByNormalized := make(map[string]string, len(aliases))
+	for requested, event := range aliases {
+		aliasesByNormalized[NormalizeCourseName(requested)] = event
+	}
+
+	students := make(map[string]int)
+	for _, request := range requests {
+		seen := make(map[string]bool)
+		for _, courses := range request.Choices {
+			for _, course := range courses {
+				if course != "" && !isEvent[course] && !seen[course] {
+					seen[course] = true
+					students[course]++
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(students))
+	for name := range students {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matches := make([]Match, 0, len(names))
+	for _, name := range names {
+		match := Match{Requested: name, Method: MatchNone, Students: students[name]}
+		normalized := NormalizeCourseName(name)
+		if event, ok := aliases[name]; ok && isEvent[event] {
+			match.Event, match.Method = event, MatchAlias
+		} else if event, ok := aliasesByNormalized[normalized]; ok && isEvent[event] {
+			match.Event, match.Method = event, MatchAlias
+		} else if event, ok := byNormalized[normalized]; ok {
+			match.Event, match.Method = event, MatchNormalized
+		} else if event, distance, ok := closestEvent(normalized, eventNames); ok {
+			match.Event, match.Method, match.Distance = event, MatchFuzzy, distance
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// closestEvent finds the event whose normalized name is the fewest edits away, if that is close
+// enough to be a likely typo rather than a different course
+func closestEvent(normalized string, eventNames []string) (string, int, bool) {
+	best, bestDistance := "", -1
+	for _, name := range eventNames {
+		distance := editDistance(normalized, NormalizeCourseName(name))
+		if bestDistance < 0 || distance < bestDistance || (distance == bestDistance && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	limit := len([]rune(normalized)) / 4
+	if limit < 2 {
+		limit = 2
+	}
+	return best, bestDistance, bestDistance >= 0 && bestDistance <= limit
+}
+
+// editDistance is the Levenshtein distance between two strings, counted in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// reconcileCourses renames the requested courses that the alias table or their normalized name
+// match to an event, and remembers the suggestions for the rest
+func (d *DataLoader) reconcileCourses() {
+	eventNames := make([]string, len(d.Events))
+	isEvent := make(map[string]bool, len(d.Events))
+	for i, course := range d.Events {
+		eventNames[i] = course.Name
+		isEvent[course.Name] = true
+	}
+	for requested, event := range d.Aliases {
+		if !isEvent[event] {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("The alias for %q names %q, which is not in %s", requested, event, EventsFile))
+		}
+	}
+
+	renames := make(map[string]string)
+	d.suggestions = make(map[string]string)
+	for _, match := range Reconcile(d.Requests, eventNames, d.Aliases) {
+		switch match.Method {
+		case MatchAlias, MatchNormalized:
+			renames[match.Requested] = match.Event
+			d.Warnings = append(d.Warnings, fmt.Sprintf("Using %q for the requested course %q (%s match)", match.Event, match.Requested, match.Method))
+		case MatchFuzzy:
+			d.suggestions[match.Requested] = match.Event
+		}
+	}
+	if len(renames) == 0 {
+		return
+	}
+	for _, request := range d.Requests {
+		for _, courses := range request.Choices {
+			for i, course := range courses {
+				if event, ok := renames[course]; ok {
+					courses[i] = event
+				}
+			}
+		}
+	}
+}
+
+// describeUnknownCourse names a course missing from the events, with the closest event if there is one
+func (d *DataLoader) describeUnknownCourse(courseName string) string {
+	if suggestion, ok := d.suggestions[courseName]; ok {
+		return fmt.Sprintf("%s (did you mean %q?)", courseName, suggestion)
+	}
+	return courseName
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm"
+)
+
+func TestNormalizeCourseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Robotics", "robotics"},
+		{"  Robotics\t 101 ", "robotics 101"},
+		{"Rock ’n’ Roll", "rock 'n' roll"},
+		{"“Improv” – Level 2", `"improv" - level 2`},
+		{"Ｃｈｅｓｓ", "chess"},
+		{"Café", "café"},
+	}
+	for _, test := range tests {
+		if got := NormalizeCourseName(test.name); got != test.want {
+			t.Errorf("NormalizeCourseName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	requests := []*algorithm.Request{
+		{Choices: map[string][]string{"AM": {"Robotics", "robotics ", "Pottery Wheel"}, "PM": {"Robotcs"}}},
+		{Choices: map[string][]string{"AM": {"robotics ", "Chess"}, "PM": {"Knitting"}}},
+	}
+	events := []string{"Robotics", "Pottery", "Chess Club"}
+	aliases := Aliases{"Chess": "Chess Club", "pottery wheel": "Pottery"}
+
+	want := []Match{
+		{Requested: "Chess", Event: "Chess Club", Method: MatchAlias, Students: 1},
+		{Requested: "Knitting", Method: MatchNone, Students: 1},
+		{Requested: "Pottery Wheel", Event: "Pottery", Method: MatchAlias, Students: 1},
+		{Requested: "Robotcs", Event: "Robotics", Method: MatchFuzzy, Distance: 1, Students: 1},
+		{Requested: "robotics ", Event: "Robotics", Method: MatchNormalized, Students: 2},
+	}
+	if got := Reconcile(requests, events, aliases); !reflect.DeepEqual(got, want) {
+		t.Errorf("Reconcile() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAliasesAreReadBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), AliasesFile)
+	aliases := Aliases{"Robotcs": "Robotics"}
+	if err := aliases.Write(path); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	read, err := ReadAliases(path)
+	if err != nil || !reflect.DeepEqual(read, aliases) {
+		t.Errorf("ReadAliases() = %v, %v, want %v", read, err, aliases)
+	}
+	if missing, err := ReadAliases(filepath.Join(t.TempDir(), AliasesFile)); err != nil || len(missing) != 0 {
+		t.Errorf("ReadAliases() of a missing file = %v, %v, want an empty table", missing, err)
+	}
+}
+
+func TestLoadRenamesReconciledCourses(t *testing.T) {
+	requests := "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+		"AM Course - 1st Choice. (Drop down option),AM Course - 2nd Choice. (Drop down option)\n" +
+		"a@x.org,A,A,Junior,robotics ,Chess\n"
+	events := "Name,Max Students,Time Slot\nRobotics,1,AM\nChess Club,1,AM\n"
+
+	if _, err := Load([]byte(requests), []byte(events), Options{}); err == nil {
+		t.Error("Load() succeeded without an alias for Chess, want it missing from the events")
+	}
+	loader, err := Load([]byte(requests), []byte(events), Options{Aliases: Aliases{"Chess": "Chess Club"}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got, want := loader.Requests[0].Choices["AM"], []string{"Robotics", "Chess Club"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("the AM choices are %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/agavris/june-academy-go/src/algorithm"
+)
+
+// SlotsFile is the table of time slots that is used when it exists in the working directory
+const SlotsFile = "slots.json"
+
+// SlotTable declares the time slots of the program in the order they take place, such as the periods
+// of a day or the days of a week. Every student takes at most one course in each of them, and a course
+// takes place in one slot or in a span of several. Names are matched regardless of case.
+type SlotTable struct {
+	Slots []string `json:"slots"`
+	// Spans maps the time slots of the courses that take up more than one slot to the slots they take up
+	Spans map[string][]string `json:"spans,omitempty"`
+	// Default lists the slots taken up by the courses whose time slot is neither a slot nor a span. When
+	// it is empty those courses are an error.
+	Default []string `json:"default,omitempty"`
+}
+
+// DefaultSlotTable has a morning and an afternoon, and the FullDay courses take up both. It has no default,
+// so a misspelled time slot is an error instead of a full day course.
+var DefaultSlotTable = SlotTable{Slots: []string{"AM", "PM"}, Spans: map[string][]string{"FullDay": {"AM", "PM"}}}
+
+// ReadSlotTable loads a slot table from a JSON file. A missing file is the default table.
+func ReadSlotTable(path string) (*SlotTable, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &DefaultSlotTable, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseSlotTable(contents)
+}
+
+// ParseSlotTable reads a slot table in JSON and checks that its spans and default take up declared slots
+func ParseSlotTable(contents []byte) (*SlotTable, error) {
+	table := &SlotTable{}
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(table); err != nil {
+		return nil, fmt.Errorf("invalid slot table: %w", err)
+	}
+	if len(table.Slots) == 0 {
+		return nil, errors.New("invalid slot table: it has no slots")
+	}
+	seen := make(map[string]string)
+	for i, slot := range table.Slots {
+		table.Slots[i] = strings.TrimSpace(slot)
+		folded := foldSlot(slot)
+		if folded == "" {
+			return nil, errors.New("invalid slot table: a slot is blank")
+		}
+		if other, ok := seen[folded]; ok {
+			return nil, fmt.Errorf("invalid slot table: %q and %q are the same slot", other, slot)
+		}
+		seen[folded] = slot
+	}
+	// A span takes up its slots in the order of the table, whatever order it lists them in
+	order := func(name string, slots []string) ([]string, error) {
+		taken := make(map[string]bool, len(slots))
+		for _, slot := range slots {
+			canonical, ok := table.Slot(slot)
+			if !ok {
+				return nil, fmt.Errorf("invalid slot table: %s takes up %q, which is not a slot", name, slot)
+			}
+			if taken[canonical] {
+				return nil, fmt.Errorf("invalid slot table: %s takes up %q twice", name, slot)
+			}
+			taken[canonical] = true
+		}
+		var ordered []string
+		for _, slot := range table.Slots {
+			if taken[slot] {
+				ordered = append(ordered, slot)
+			}
+		}
+		return ordered, nil
+	}
+	for name, slots := range table.Spans {
+		if foldSlot(name) == "" {
+			return nil, errors.New("invalid slot table: a span is blank")
+		}
+		if _, ok := seen[foldSlot(name)]; ok {
+			return nil, fmt.Errorf("invalid slot table: span %q has the name of a slot", name)
+		}
+		if len(slots) == 0 {
+			return nil, fmt.Errorf("invalid slot table: span %q takes up no slots", name)
+		}
+		ordered, err := order(fmt.Sprintf("span %q", name), slots)
+		if err != nil {
+			return nil, err
+		}
+		table.Spans[name] = ordered
+	}
+	if len(table.Default) > 0 {
+		ordered, err := order("the default", table.Default)
+		if err != nil {
+			return nil, err
+		}
+		table.Default = ordered
+	}
+	return table, nil
+}
+
+// Slot returns the name of a slot as the table declares it, and false when the table has no such slot
+func (t *SlotTable) Slot(name string) (string, bool) {
+	for _, slot := range t.Slots {
+		if foldSlot(slot) == foldSlot(name) {
+			return slot, true
+		}
+	}
+	return "", false
+}
+
+// canonicalChoices keys the choices of every request by the slot names the table declares, so choices
+// mapped to a slot spelled in another case still count. Slots the table doesn't declare keep their spelling.
+func (t *SlotTable) canonicalChoices(requests []*algorithm.Request) {
+	for _, request := range requests {
+		choices := make(map[string][]string, len(request.Choices))
+		for _, slot := range sortedSlots(request.Choices) {
+			name, ok := t.Slot(slot)
+			if !ok {
+				name = slot
+			}
+			choices[name] = append(choices[name], request.Choices[slot]...)
+		}
+		request.Choices = choices
+	}
+}
+
+// Resolve returns the time slot of a course as the table names it together with the slots it takes
+// up, and false when the table has neither a slot nor a span of that name and no default
+func (t *SlotTable) Resolve(timeSlot string) (string, []string, bool) {
+	if name, slots, ok := t.lookup(timeSlot); ok {
+		return name, slots, true
+	}
+	if len(t.Default) > 0 {
+		return strings.TrimSpace(timeSlot), t.Default, true
+	}
+	return "", nil, false
+}
+
+// lookup returns the slot or span of the given name together with the slots it takes up, and false when
+// the table has neither
+func (t *SlotTable) lookup(name string) (string, []string, bool) {
+	if slot, ok := t.Slot(name); ok {
+		return slot, []string{slot}, true
+	}
+	for _, span := range t.spanNames() {
+		if foldSlot(span) == foldSlot(name) {
+			return span, t.Spans[span], true
+		}
+	}
+	return "", nil, false
+}
+
+// spanNames returns the names of the spans in alphabetical order
+func (t *SlotTable) spanNames() []string {
+	names := make([]string, 0, len(t.Spans))
+	for name := range t.Spans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names returns the slots and spans of the table, for messages
+func (t *SlotTable) Names() string {
+	return strings.Join(append(append([]string(nil), t.Slots...), t.spanNames()...), ", ")
+}
+
+func foldSlot(slot string) string {
+	return strings.ToLower(strings.TrimSpace(slot))
+}
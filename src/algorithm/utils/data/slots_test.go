@@ -0,0 +1,70 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChoicesAreKeyedByTheDeclaredSlotName(t *testing.T) {
+	columns := &ColumnMapping{
+		Email:     "Email",
+		FirstName: "First",
+		LastName:  "Last",
+		Grade:     "Grade",
+		Choices:   map[string][]string{"am": {"Morning 1", "Morning 2"}, " Pm": {"Afternoon 1"}},
+	}
+	requests := "Email,First,Last,Grade,Morning 1,Morning 2,Afternoon 1\n" +
+		"a@x.org,A,A,Junior,X,Y,Z\n"
+	events := "Name,Max Students,Time Slot\nX,1,AM\nY,1,AM\nZ,1,PM\n"
+
+	loader, err := Load([]byte(requests), []byte(events), Options{Columns: columns})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[string][]string{"AM": {"X", "Y"}, "PM": {"Z"}}
+	if got := loader.Requests[0].Choices; !reflect.DeepEqual(got, want) {
+		t.Errorf("Choices = %v, want %v", got, want)
+	}
+	if len(loader.Courses) != 3 || len(loader.SlotProblems) != 0 {
+		t.Errorf("loaded %d courses with the slot problems %v, want the 3 chosen courses", len(loader.Courses), loader.SlotProblems)
+	}
+	if report := Validate([]byte(requests), []byte(events), Options{Columns: columns}); len(report.Problems) != 0 {
+		t.Errorf("Validate found %v, want no problems", report.Problems)
+	}
+}
+
+func TestUnknownEventSlotIsAnErrorWithoutADefault(t *testing.T) {
+	requests := "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+		"AM Course - 1st Choice. (Drop down option)\n" +
+		"a@x.org,A,A,Junior,X\n"
+	tests := []struct {
+		name   string
+		events string
+		slots  *SlotTable
+		// want lists the slots the course takes up, none when its time slot is an error
+		want []string
+	}{
+		{"full day", "Name,Max Students,Time Slot\nX,1,fullday\n", nil, []string{"AM", "PM"}},
+		{"misspelled", "Name,Max Students,Time Slot\nX,1,Fulday\n", nil, nil},
+		{"named default", "Name,Max Students,Time Slot\nX,1,Fulday\n", &SlotTable{Slots: []string{"AM", "PM"}, Default: []string{"AM", "PM"}}, []string{"AM", "PM"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := Options{Slots: test.slots}
+			loader, err := Load([]byte(requests), []byte(test.events), options)
+			report := Validate([]byte(requests), []byte(test.events), options)
+			if test.want == nil {
+				if err == nil || report.Errors != 1 || report.Problems[0].Kind != "unknown-slot" || report.Problems[0].Line != 2 {
+					t.Errorf("Load() error = %v and Validate found %+v, want the unknown slot on line 2", err, report.Problems)
+				}
+				return
+			}
+			if err != nil || report.Errors != 0 {
+				t.Fatalf("Load() error = %v and Validate found %+v, want no errors", err, report.Problems)
+			}
+			if got := loader.Courses[0].Slots; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("the course takes up %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
+	"github.com/agavris/june-academy-go/src/imp"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is one finding of the validation. Line is zero for problems that concern a whole file.
+type Problem struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.File, p.Severity, p.Message)
+}
+
+// Report lists every problem found in the input files
+type Report struct {
+	Problems []Problem `json:"problems"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+}
+
+func (r *Report) add(severity Severity, kind, file string, line int, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, Problem{
+		Severity: severity,
+		Kind:     kind,
+		File:     file,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+	if severity == SeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+// Validate checks the contents of a requests and an events file and reports every problem at once.
+// Errors keep students from being scheduled the way they asked, warnings are worth a look.
+func Validate(requests, eventsFile []byte, options Options) *Report {
+	report := &Report{Problems: []Problem{}}
+
+	parsed, err := ParseRequests(requests, options.Columns)
+	if err != nil {
+		report.add(SeverityError, "invalid-requests", RequestsFile, 0, "%v", err)
+	}
+	courses, invalid, err := events.ParseCourses(bytes.NewReader(eventsFile))
+	var headerErr *events.RecordError
+	if errors.As(err, &headerErr) {
+		report.add(SeverityError, "invalid-events", EventsFile, headerErr.Line, "%v", headerErr.Err)
+	} else if err != nil {
+		report.add(SeverityError, "invalid-events", EventsFile, 0, "%v", err)
+	}
+	for _, recordErr := range invalid {
+		message := recordErr.Err.Error()
+		if recordErr.Column != "" {
+			message = recordErr.Column + ": " + message
+		}
+		if errors.Is(recordErr, events.ErrUnknownColumn) {
+			report.add(SeverityWarning, "unknown-column", EventsFile, recordErr.Line, "unknown column %q is ignored", recordErr.Column)
+		} else {
+			report.add(SeverityError, "invalid-event", EventsFile, recordErr.Line, "%s, the event is skipped", message)
+		}
+	}
+	timeSlots := events.MapCoursesToTimeSlots(courses)
+	table := options.SlotTable()
+	table.canonicalChoices(parsed)
+	slotsOf := make(map[string][]string, len(timeSlots))
+	for _, course := range courses {
+		if _, ok := slotsOf[course.Name]; ok {
+			continue
+		}
+		_, slots, ok := table.Resolve(course.TimeSlot)
+		if !ok {
+			report.add(SeverityError, "unknown-slot", EventsFile, course.Line, "%q takes place in the time slot %q, expected one of %s", course.Name, course.TimeSlot, table.Names())
+		}
+		slotsOf[course.Name] = slots
+	}
+	grades := options.gradeTable()
+	restricted := make(map[string]*imp.Course)
+	for _, course := range courses {
+		if _, ok := restricted[course.Name]; ok || len(course.Grades) == 0 {
+			continue
+		}
+		restricted[course.Name] = &imp.Course{CourseName: course.Name, TimeSlot: course.TimeSlot, Grades: course.Grades}
+		for _, grade := range course.Grades {
+			if !grades.Known(grade) {
+				report.add(SeverityWarning, "unknown-event-grade", EventsFile, course.Line, "%q is open to the unknown grade %q, expected one of %s", course.Name, grade, strings.Join(grades.Names(), ", "))
+			}
+		}
+	}
+
+	policy, err := ParseDuplicatePolicy(options.Duplicates)
+	if err != nil {
+		report.add(SeverityError, "invalid-options", RequestsFile, 0, "%v", err)
+		policy = DuplicatesLatest
+	}
+	parsed, duplicates := Deduplicate(parsed, policy)
+	for _, duplicate := range duplicates {
+		for _, line := range duplicate.Removed {
+			if policy == DuplicatesReject {
+				report.add(SeverityError, "duplicate-email", RequestsFile, line, "%s submitted the form more than once", duplicate.Email)
+			} else {
+				report.add(SeverityWarning, "duplicate-email", RequestsFile, line, "%s also submitted the form on line %d, which is kept instead of this request (%s)", duplicate.Email, duplicate.Kept, policy)
+			}
+		}
+	}
+
+	// Check the requests the way the loader will schedule them, with the course names it reconciles
+	loader := &DataLoader{Options: options, Requests: parsed, Events: courses}
+	loader.reconcileCourses()
+	for _, warning := range loader.Warnings {
+		report.add(SeverityWarning, "renamed-course", RequestsFile, 0, "%s", warning)
+	}
+	locks, lockProblems := checkLocks(options.Locks, parsed, courses, options.SlotTable())
+	for _, problem := range lockProblems {
+		report.add(SeverityError, "invalid-lock", LocksFile, problem.line, "%s", problem.message)
+	}
+	_, groupProblems := checkGroups(options.Groups, parsed, options.SlotTable())
+	for _, problem := range groupProblems {
+		report.add(SeverityError, "invalid-group", GroupsFile, problem.line, "%s", problem.message)
+	}
+	_, apartProblems := checkKeepApart(options.Apart, parsed, locks, courses)
+	for _, problem := range apartProblems {
+		report.add(SeverityError, "invalid-keep-apart", options.ApartSource(), problem.line, "%s", problem.message)
+	}
+	for _, problem := range checkBalance(options.Balance, parsed, courses) {
+		report.add(SeverityError, "invalid-balance-rule", BalanceFile, 0, "%s", problem)
+	}
+
+	requested := make(map[string]bool)
+	// Unknown, misplaced and ineligible courses are reported once per course with the lines they appear on,
+	// and the time slots missing from the slot table once per slot
+	undeclared := newLineGroups()
+	unknown := newLineGroups()
+	misplaced := newLineGroups()
+	ineligible := newLineGroups()
+	for _, request := range parsed {
+		if strings.TrimSpace(request.FirstName) == "" || strings.TrimSpace(request.LastName) == "" {
+			report.add(SeverityWarning, "blank-name", RequestsFile, request.Line, "the student's first or last name is blank")
+		}
+		if NormalizeEmail(request.Email) == "" {
+			report.add(SeverityError, "blank-email", RequestsFile, request.Line, "the email address is blank")
+		}
+		if tier, ok := grades.Tier(request.Grade); !ok {
+			report.add(SeverityError, "unknown-grade", RequestsFile, request.Line, "unknown grade %q, expected one of %s", request.Grade, strings.Join(grades.Names(), ", "))
+		} else if !grades.Known(request.Grade) {
+			report.add(SeverityWarning, "unknown-grade", RequestsFile, request.Line, "unknown grade %q, the student is scheduled in the default tier %d", request.Grade, tier)
+		}
+
+		for _, slot := range sortedSlots(request.Choices) {
+			if _, ok := table.Slot(slot); !ok {
+				if hasChoices(request.Choices[slot]) {
+					undeclared.add(slot, request.Line)
+				}
+				continue
+			}
+			seen := make(map[string]int)
+			for i, course := range request.Choices[slot] {
+				if course == "" {
+					continue
+				}
+				requested[course] = true
+				if previous, ok := seen[course]; ok {
+					report.add(SeverityWarning, "duplicate-choice", RequestsFile, request.Line, "%s choice %d repeats choice %d, %q", slot, i+1, previous+1, course)
+				} else {
+					seen[course] = i
+				}
+
+				timeSlot, ok := timeSlots[course]
+				if !ok {
+					unknown.add(course, request.Line)
+				} else if len(slotsOf[course]) == 0 {
+					// The time slot of the course is already reported as unknown
+				} else if first := slotsOf[course][0]; first != slot && len(slotsOf[course]) > 1 {
+					misplaced.add(fmt.Sprintf("%q takes place in the %s slot, which starts in the %s, but is chosen as a %s choice", course, timeSlot, first, slot), request.Line)
+				} else if first != slot {
+					misplaced.add(fmt.Sprintf("%q takes place in the %s slot but is chosen as a %s choice", course, timeSlot, slot), request.Line)
+				}
+				if restriction, ok := restricted[course]; ok && !restriction.IsEligible(request.Grade) {
+					ineligible.add(fmt.Sprintf("%q is only open to %s but is requested by a %s", course, strings.Join(restriction.Grades, ", "), request.Grade), request.Line)
+				}
+			}
+		}
+	}
+
+	for _, group := range undeclared.groups {
+		report.add(SeverityError, "unknown-slot", RequestsFile, group.lines[0], "choices for the time slot %q on %s, expected one of %s", group.key, describeLines(group.lines), strings.Join(table.Slots, ", "))
+	}
+	for _, group := range unknown.groups {
+		message := fmt.Sprintf("course %q is not in %s, requested on %s", group.key, EventsFile, describeLines(group.lines))
+		if suggestion, ok := loader.suggestions[group.key]; ok {
+			message += fmt.Sprintf(", did you mean %q?", suggestion)
+		}
+		report.add(SeverityError, "unknown-course", RequestsFile, group.lines[0], "%s", message)
+	}
+	for _, group := range misplaced.groups {
+		report.add(SeverityError, "wrong-slot", RequestsFile, group.lines[0], "%s on %s", group.key, describeLines(group.lines))
+	}
+	for _, group := range ineligible.groups {
+		report.add(SeverityWarning, "ineligible-choice", RequestsFile, group.lines[0], "%s on %s, the choice is skipped", group.key, describeLines(group.lines))
+	}
+	for _, course := range courses {
+		if !requested[course.Name] {
+			// Report a course once, however many rows its sections take
+			requested[course.Name] = true
+			report.add(SeverityWarning, "unrequested-event", EventsFile, 0, "no student requested %q", course.Name)
+		}
+	}
+	return report
+}
+
+type lineGroup struct {
+	key   string
+	lines []int
+}
+
+// lineGroups collects lines under a key, keeping the keys in the order they were first seen
+type lineGroups struct {
+	groups []*lineGroup
+	byKey  map[string]*lineGroup
+}
+
+func newLineGroups() *lineGroups {
+	return &lineGroups{byKey: make(map[string]*lineGroup)}
+}
+
+func (g *lineGroups) add(key string, line int) {
+	group, ok := g.byKey[key]
+	if !ok {
+		group = &lineGroup{key: key}
+		g.byKey[key] = group
+		g.groups = append(g.groups, group)
+	}
+	group.lines = append(group.lines, line)
+}
+
+// describeLines lists the first few lines and counts the rest
+func describeLines(lines []int) string {
+	const shown = 5
+	parts := make([]string, 0, shown)
+	for i, line := range lines {
+		if i == shown {
+			break
+		}
+		parts = append(parts, fmt.Sprint(line))
+	}
+	description := "line " + strings.Join(parts, ", ")
+	if len(lines) > 1 {
+		description = "lines " + strings.Join(parts, ", ")
+	}
+	if len(lines) > shown {
+		description += fmt.Sprintf(" and %d more", len(lines)-shown)
+	}
+	return description
+}
+
+// hasChoices reports whether any of the choices names a course
+func hasChoices(choices []string) bool {
+	for _, course := range choices {
+		if course != "" {
+			return true
+		}
+	}
+	return false
+}
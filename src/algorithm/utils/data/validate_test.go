@@ -0,0 +1,85 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+const validateHeader = "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+	"AM Course - 1st Choice. (Drop down option),PM Course - 1st Choice. (Drop down option)\n"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests string
+		events   string
+		// problems lists the severity, kind, file and line of every problem, in the order they are reported
+		problems []Problem
+		errors   int
+		warnings int
+	}{
+		{
+			name:     "clean input",
+			requests: validateHeader + "a@x.org,A,A,Junior,X,Y\n",
+			events:   "Name,Max Students,Time Slot\nX,1,AM\nY,1,PM\n",
+		},
+		{
+			name: "problems in the requests",
+			requests: validateHeader +
+				"a@x.org,A,,Junior,X,Nope\n" +
+				",B,B,Junior,Y,\n" +
+				"c@x.org,C,C,Astronaut,X,Nope\n",
+			events: "Name,Max Students,Time Slot\nX,1,AM\nY,1,PM\n",
+			problems: []Problem{
+				{Severity: SeverityWarning, Kind: "blank-name", File: RequestsFile, Line: 2},
+				{Severity: SeverityError, Kind: "blank-email", File: RequestsFile, Line: 3},
+				{Severity: SeverityError, Kind: "unknown-grade", File: RequestsFile, Line: 4},
+				{Severity: SeverityError, Kind: "unknown-course", File: RequestsFile, Line: 2},
+				{Severity: SeverityError, Kind: "wrong-slot", File: RequestsFile, Line: 3},
+			},
+			errors:   4,
+			warnings: 1,
+		},
+		{
+			name:     "problems in the events",
+			requests: validateHeader + "a@x.org,A,A,Junior,X,Y\n",
+			events:   "Name,Max Students,Time Slot,Parking\nX,1,AM,\nY,many,PM,\nZ,1,PM,\n",
+			problems: []Problem{
+				{Severity: SeverityWarning, Kind: "unknown-column", File: EventsFile, Line: 1},
+				{Severity: SeverityError, Kind: "invalid-event", File: EventsFile, Line: 3},
+				{Severity: SeverityError, Kind: "unknown-course", File: RequestsFile, Line: 2},
+				{Severity: SeverityWarning, Kind: "unrequested-event", File: EventsFile},
+			},
+			errors:   2,
+			warnings: 2,
+		},
+		{
+			name:     "ineligible choice",
+			requests: validateHeader + "a@x.org,A,A,Freshman,X,Y\nb@x.org,B,B,Freshman,X,Y\n",
+			events:   "Name,Max Students,Time Slot,Grades\nX,2,AM,Junior\nY,2,PM,\n",
+			problems: []Problem{
+				{Severity: SeverityWarning, Kind: "ineligible-choice", File: RequestsFile, Line: 2},
+			},
+			warnings: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := Validate([]byte(test.requests), []byte(test.events), Options{})
+			problems := []Problem{}
+			for _, problem := range report.Problems {
+				problem.Message = ""
+				problems = append(problems, problem)
+			}
+			if test.problems == nil {
+				test.problems = []Problem{}
+			}
+			if !reflect.DeepEqual(problems, test.problems) {
+				t.Errorf("problems = %+v, want %+v", problems, test.problems)
+			}
+			if report.Errors != test.errors || report.Warnings != test.warnings {
+				t.Errorf("found %d errors and %d warnings, want %d and %d", report.Errors, report.Warnings, test.errors, test.warnings)
+			}
+		})
+	}
+}
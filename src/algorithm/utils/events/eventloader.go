@@ -2,15 +2,90 @@ package events
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
+// Course is a row of the events file. Only the name, capacity and time slot are required, the
+// other fields keep their zero value when the file has no column for them, except Sections which
+// defaults to one. MaxStudents and MinStudents apply to every section of the row. A course whose
+// sections differ in capacity, instructor or room has a row for each of them.
 type Course struct {
 	Name        string
 	MaxStudents int
 	TimeSlot    string
+	MinStudents int
+	// Grades lists the grades that may take the course, every grade when empty
+	Grades      []string
+	Instructor  string
+	Room        string
+	Description string
+	Sections    int
+	Cost        float64
+	// Line is the line of the events file the course was read from
+	Line int
+}
+
+// The columns of the events file. A file without a header row has the first three, in this order.
+const (
+	ColumnName        = "name"
+	ColumnMaxStudents = "max students"
+	ColumnTimeSlot    = "time slot"
+	ColumnMinStudents = "min students"
+	ColumnGrades      = "grades"
+	ColumnInstructor  = "instructor"
+	ColumnRoom        = "room"
+	ColumnDescription = "description"
+	ColumnSections    = "sections"
+	ColumnCost        = "cost"
+)
+
+var requiredColumns = []string{ColumnName, ColumnMaxStudents, ColumnTimeSlot}
+
+// columnNames maps the folded spellings a header may use to the column they name
+var columnNames = map[string]string{
+	"name": ColumnName, "course": ColumnName, "coursename": ColumnName, "event": ColumnName,
+	"maxstudents": ColumnMaxStudents, "capacity": ColumnMaxStudents, "max": ColumnMaxStudents, "maxenrollment": ColumnMaxStudents,
+	"timeslot": ColumnTimeSlot, "slot": ColumnTimeSlot, "time": ColumnTimeSlot,
+	"minstudents": ColumnMinStudents, "minenrollment": ColumnMinStudents, "minimumenrollment": ColumnMinStudents, "min": ColumnMinStudents,
+	"grades": ColumnGrades, "eligiblegrades": ColumnGrades, "grade": ColumnGrades,
+	"instructor": ColumnInstructor, "teacher": ColumnInstructor,
+	"room": ColumnRoom, "location": ColumnRoom,
+	"description": ColumnDescription,
+	"sections":    ColumnSections, "numberofsections": ColumnSections,
+	"cost": ColumnCost, "fee": ColumnCost,
+}
+
+var (
+	ErrMissingColumn   = errors.New("missing required column")
+	ErrUnknownColumn   = errors.New("unknown column")
+	ErrMissingValue    = errors.New("missing value")
+	ErrInvalidNumber   = errors.New("invalid number")
+	ErrDuplicateCourse = errors.New("duplicate course")
+)
+
+// RecordError reports a problem with a record of the events file. Column is empty when the problem
+// concerns the whole record.
+type RecordError struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("line %d, %s: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
 }
 
 // ReadCourses reads courses from a CSV file and returns a slice of Course
@@ -21,35 +96,186 @@ func ReadCourses(filePath string) ([]Course, error) {
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, err
+	courses, invalid, err := ParseCourses(file)
+	for _, recordErr := range invalid {
+		fmt.Println("Skipped record of", filePath+":", recordErr)
 	}
+	return courses, err
+}
+
+// ParseCourses reads courses from an events CSV, either with a header row naming its columns or
+// in the headerless name, capacity, time slot layout. Rows that repeat a name add sections to the
+// course and must agree with its first row on the time slot and grades. Records with invalid values
+// are skipped and returned as record errors, as are unknown columns, which are ignored. A file that
+// can't be read at all, or whose header lacks a required column, fails with the returned error.
+func ParseCourses(r io.Reader) ([]Course, []*RecordError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
 
 	var courses []Course
-	for _, record := range records {
-		maxStudents, err := strconv.Atoi(record[1])
+	var invalid []*RecordError
+	var columns []string
+	seen := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			fmt.Printf("Error converting student count: %v\n", err)
-			continue // Skip records with invalid student counts
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if columns == nil {
+			if header, unknown, ok := parseHeader(record); ok {
+				for _, name := range unknown {
+					invalid = append(invalid, &RecordError{Line: line, Column: name, Err: ErrUnknownColumn})
+				}
+				if err := checkColumns(header, line); err != nil {
+					return nil, nil, err
+				}
+				columns = header
+				continue
+			}
+			columns = requiredColumns
+		}
+
+		course, recordErr := parseCourse(record, columns, line)
+		if recordErr != nil {
+			invalid = append(invalid, recordErr)
+			continue
+		}
+		if first, ok := seen[course.Name]; ok {
+			if recordErr := checkSection(course, courses[first]); recordErr != nil {
+				invalid = append(invalid, recordErr)
+				continue
+			}
+		} else {
+			seen[course.Name] = len(courses)
 		}
-		courses = append(courses, Course{
-			Name:        record[0],
-			MaxStudents: maxStudents,
-			TimeSlot:    record[2],
-		})
+		courses = append(courses, course)
 	}
-	return courses, nil
+	return courses, invalid, nil
 }
 
-// MapCoursesToMaxStudents maps course names to their maximum number of students
-func MapCoursesToMaxStudents(courses []Course) map[string]int {
-	courseToMax := make(map[string]int)
-	for _, course := range courses {
-		courseToMax[course.Name] = course.MaxStudents
+// parseHeader recognizes a header row by its capacity column not being a number while one of its
+// fields names a column. It returns the column of every field, empty for the unknown ones.
+func parseHeader(record []string) ([]string, []string, bool) {
+	if len(record) > 1 {
+		if _, err := strconv.Atoi(strings.TrimSpace(record[1])); err == nil {
+			return nil, nil, false
+		}
+	}
+	columns := make([]string, len(record))
+	var unknown []string
+	known := false
+	for i, field := range record {
+		if column, ok := columnNames[foldHeader(field)]; ok {
+			columns[i] = column
+			known = true
+		} else if strings.TrimSpace(field) != "" {
+			unknown = append(unknown, strings.TrimSpace(field))
+		}
+	}
+	return columns, unknown, known
+}
+
+func checkColumns(columns []string, line int) error {
+	var missing []string
+	for _, required := range requiredColumns {
+		found := false
+		for _, column := range columns {
+			found = found || column == required
+		}
+		if !found {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) > 0 {
+		return &RecordError{Line: line, Err: fmt.Errorf("%w: %s", ErrMissingColumn, strings.Join(missing, ", "))}
+	}
+	return nil
+}
+
+func foldHeader(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, header)
+}
+
+// checkSection makes sure a row that adds sections to a course describes the same course as its first row
+func checkSection(course, first Course) *RecordError {
+	if course.TimeSlot != first.TimeSlot {
+		return &RecordError{Line: course.Line, Column: ColumnTimeSlot, Err: fmt.Errorf("%w %q, defined on line %d with the time slot %s", ErrDuplicateCourse, course.Name, first.Line, first.TimeSlot)}
+	}
+	if strings.Join(course.Grades, ",") != strings.Join(first.Grades, ",") {
+		return &RecordError{Line: course.Line, Column: ColumnGrades, Err: fmt.Errorf("%w %q, defined on line %d for other grades", ErrDuplicateCourse, course.Name, first.Line)}
+	}
+	return nil
+}
+
+func parseCourse(record []string, columns []string, line int) (Course, *RecordError) {
+	course := Course{Sections: 1, Line: line}
+	values := make(map[string]string, len(columns))
+	for i, column := range columns {
+		if column != "" && i < len(record) {
+			values[column] = strings.TrimSpace(record[i])
+		}
+	}
+	for _, required := range requiredColumns {
+		if values[required] == "" {
+			return course, &RecordError{Line: line, Column: required, Err: ErrMissingValue}
+		}
+	}
+
+	course.Name = values[ColumnName]
+	course.TimeSlot = values[ColumnTimeSlot]
+	course.Instructor = values[ColumnInstructor]
+	course.Room = values[ColumnRoom]
+	course.Description = values[ColumnDescription]
+	if grades := values[ColumnGrades]; grades != "" {
+		for _, grade := range strings.FieldsFunc(grades, func(r rune) bool { return strings.ContainsRune(",;|/", r) }) {
+			if grade = strings.TrimSpace(grade); grade != "" {
+				course.Grades = append(course.Grades, grade)
+			}
+		}
+	}
+
+	integers := []struct {
+		column  string
+		value   *int
+		minimum int
+	}{
+		{ColumnMaxStudents, &course.MaxStudents, 0},
+		{ColumnMinStudents, &course.MinStudents, 0},
+		{ColumnSections, &course.Sections, 1},
+	}
+	for _, integer := range integers {
+		value, ok := values[integer.column]
+		if !ok || value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < integer.minimum {
+			return course, &RecordError{Line: line, Column: integer.column, Err: fmt.Errorf("%w %q, expected a whole number of at least %d", ErrInvalidNumber, value, integer.minimum)}
+		}
+		*integer.value = number
+	}
+	if value := values[ColumnCost]; value != "" {
+		cost, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+		if err != nil || cost < 0 {
+			return course, &RecordError{Line: line, Column: ColumnCost, Err: fmt.Errorf("%w %q", ErrInvalidNumber, value)}
+		}
+		course.Cost = cost
+	}
+	if course.MinStudents > course.MaxStudents {
+		return course, &RecordError{Line: line, Column: ColumnMinStudents, Err: fmt.Errorf("%w: %d is more than the capacity of %d", ErrInvalidNumber, course.MinStudents, course.MaxStudents)}
 	}
-	return courseToMax
+	return course, nil
 }
 
 // MapCoursesToTimeSlots maps course names to their time slots
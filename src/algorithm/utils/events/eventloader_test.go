@@ -0,0 +1,101 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCourses(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		courses []Course
+		// invalid lists the error and column of every skipped record or ignored column, in order
+		invalid []error
+		columns []string
+	}{
+		{
+			name: "headerless",
+			file: "Robotics,12,AM\nPottery,8,FullDay\n",
+			courses: []Course{
+				{Name: "Robotics", MaxStudents: 12, TimeSlot: "AM", Sections: 1, Line: 1},
+				{Name: "Pottery", MaxStudents: 8, TimeSlot: "FullDay", Sections: 1, Line: 2},
+			},
+		},
+		{
+			name: "every column under its aliases",
+			file: "Course,Capacity,Slot,Min,Eligible Grades,Teacher,Location,Description,Number of Sections,Fee\n" +
+				"Robotics,12,AM,4,Junior; Sophomore,Ada,Lab 1,Build a robot,2,$15.50\n",
+			courses: []Course{{
+				Name: "Robotics", MaxStudents: 12, TimeSlot: "AM", MinStudents: 4, Grades: []string{"Junior", "Sophomore"},
+				Instructor: "Ada", Room: "Lab 1", Description: "Build a robot", Sections: 2, Cost: 15.5, Line: 2,
+			}},
+		},
+		{
+			name: "columns in any order with an unknown one",
+			file: "Time Slot,Name,Max Students,Parking\nPM,Pottery,8,Lot B\n",
+			courses: []Course{
+				{Name: "Pottery", MaxStudents: 8, TimeSlot: "PM", Sections: 1, Line: 2},
+			},
+			invalid: []error{ErrUnknownColumn},
+			columns: []string{"Parking"},
+		},
+		{
+			name: "invalid records are skipped",
+			file: "Name,Max Students,Time Slot,Min Students,Sections,Cost\n" +
+				"Robotics,twelve,AM,,,\n" +
+				",8,PM,,,\n" +
+				"Pottery,8,PM,9,,\n" +
+				"Chess,8,PM,,0,\n" +
+				"Choir,8,PM,,,free\n" +
+				"Drama,8,PM,,,\n",
+			courses: []Course{
+				{Name: "Drama", MaxStudents: 8, TimeSlot: "PM", Sections: 1, Line: 7},
+			},
+			invalid: []error{ErrInvalidNumber, ErrMissingValue, ErrInvalidNumber, ErrInvalidNumber, ErrInvalidNumber},
+			columns: []string{ColumnMaxStudents, ColumnName, ColumnMinStudents, ColumnSections, ColumnCost},
+		},
+		{
+			name: "repeated rows add sections of the same course",
+			file: "Name,Max Students,Time Slot,Room\n" +
+				"Robotics,12,AM,Lab 1\n" +
+				"Robotics,10,AM,Lab 2\n" +
+				"Robotics,10,PM,Lab 3\n",
+			courses: []Course{
+				{Name: "Robotics", MaxStudents: 12, TimeSlot: "AM", Room: "Lab 1", Sections: 1, Line: 2},
+				{Name: "Robotics", MaxStudents: 10, TimeSlot: "AM", Room: "Lab 2", Sections: 1, Line: 3},
+			},
+			invalid: []error{ErrDuplicateCourse},
+			columns: []string{ColumnTimeSlot},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			courses, invalid, err := ParseCourses(strings.NewReader(test.file))
+			if err != nil {
+				t.Fatalf("ParseCourses: %v", err)
+			}
+			if !reflect.DeepEqual(courses, test.courses) {
+				t.Errorf("courses = %+v, want %+v", courses, test.courses)
+			}
+			if len(invalid) != len(test.invalid) {
+				t.Fatalf("record errors = %v, want %d of them", invalid, len(test.invalid))
+			}
+			for i, recordErr := range invalid {
+				if !errors.Is(recordErr, test.invalid[i]) || recordErr.Column != test.columns[i] {
+					t.Errorf("record error %d = %v in the %q column, want %v in the %q column", i, recordErr, recordErr.Column, test.invalid[i], test.columns[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCoursesFailsWithoutARequiredColumn(t *testing.T) {
+	_, _, err := ParseCourses(strings.NewReader("Name,Capacity,Room\nRobotics,12,Lab 1\n"))
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || !errors.Is(err, ErrMissingColumn) || recordErr.Line != 1 || !strings.Contains(err.Error(), ColumnTimeSlot) {
+		t.Errorf("ParseCourses() error = %v, want the missing %s column on line 1", err, ColumnTimeSlot)
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Match the course names of jadata.csv to events.csv.",
+	Long: `Propose an event for every course name in jadata.csv that is not in events.csv. Alias and normalized
+matches are applied when scheduling, fuzzy matches are only suggested until they are approved into the
+alias table with --interactive or --accept.`,
+	RunE: runReconcile,
+}
+
+var reconcileFormat string
+var reconcileInteractive bool
+var reconcileAccept bool
+
+func init() {
+	reconcileCmd.Flags().StringVar(&reconcileFormat, "format", "text", "Output format of the report, text or json.")
+	reconcileCmd.Flags().BoolVarP(&reconcileInteractive, "interactive", "i", false, "Ask for approval of every proposed match and save the approved ones to the alias table.")
+	reconcileCmd.Flags().BoolVar(&reconcileAccept, "accept", false, "Save every fuzzy match to the alias table.")
+	addDataFlags(reconcileCmd.Flags())
+	rootCmd.AddCommand(reconcileCmd)
+}
+
+func runReconcile(cmd *cobra.Command, args []string) error {
+	if reconcileFormat != "text" && reconcileFormat != "json" {
+		return fmt.Errorf("unknown format %q, expected text or json", reconcileFormat)
+	}
+	options, err := readDataOptions()
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(data.RequestsFile)
+	if err != nil {
+		return err
+	}
+	requests, err := data.ParseRequests(contents, options.Columns)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", data.RequestsFile, err)
+	}
+	contents, err = os.ReadFile(data.EventsFile)
+	if err != nil {
+		return err
+	}
+	courses, _, err := events.ParseCourses(bytes.NewReader(contents))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", data.EventsFile, err)
+	}
+	eventNames := make([]string, len(courses))
+	isEvent := make(map[string]bool, len(courses))
+	for i, course := range courses {
+		eventNames[i] = course.Name
+		isEvent[course.Name] = true
+	}
+
+	matches := data.Reconcile(requests, eventNames, options.Aliases)
+	if reconcileFormat == "json" && !reconcileInteractive {
+		output, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+	} else if !reconcileInteractive {
+		for _, match := range matches {
+			fmt.Println(describeMatch(match))
+		}
+		if len(matches) == 0 {
+			fmt.Println("Every requested course is in", data.EventsFile)
+		}
+	}
+
+	approved := 0
+	input := bufio.NewReader(os.Stdin)
+	for _, match := range matches {
+		event := ""
+		switch {
+		case reconcileInteractive && match.Method == data.MatchFuzzy:
+			fmt.Println(describeMatch(match))
+			answer := prompt(input, fmt.Sprintf("Use %q for %q? [y/N or another event name] ", match.Event, match.Requested))
+			if strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes") {
+				event = match.Event
+			} else if answer != "" && !strings.EqualFold(answer, "n") && !strings.EqualFold(answer, "no") {
+				event = answer
+			}
+		case reconcileInteractive && match.Method == data.MatchNone:
+			fmt.Println(describeMatch(match))
+			event = prompt(input, fmt.Sprintf("Event name for %q, empty to skip: ", match.Requested))
+		case reconcileAccept && match.Method == data.MatchFuzzy:
+			event = match.Event
+		}
+		if event == "" {
+			continue
+		}
+		if !isEvent[event] {
+			fmt.Printf("%q is not in %s, skipped\n", event, data.EventsFile)
+			continue
+		}
+		if options.Aliases == nil {
+			options.Aliases = data.Aliases{}
+		}
+		options.Aliases[match.Requested] = event
+		approved++
+	}
+
+	if approved > 0 {
+		if err := options.Aliases.Write(aliasesPath); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Saved %d aliases to %s\n", approved, aliasesPath)
+	}
+	return nil
+}
+
+func describeMatch(match data.Match) string {
+	students := fmt.Sprintf("%d students", match.Students)
+	if match.Students == 1 {
+		students = "1 student"
+	}
+	switch match.Method {
+	case data.MatchNone:
+		return fmt.Sprintf("%q: no match (%s)", match.Requested, students)
+	case data.MatchFuzzy:
+		return fmt.Sprintf("%q -> %q (fuzzy, %d edits, %s)", match.Requested, match.Event, match.Distance, students)
+	default:
+		return fmt.Sprintf("%q -> %q (%s, %s)", match.Requested, match.Event, match.Method, students)
+	}
+}
+
+func prompt(input *bufio.Reader, question string) string {
+	fmt.Print(question)
+	answer, _ := input.ReadString('\n')
+	return strings.TrimSpace(answer)
+}
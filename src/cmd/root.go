@@ -1,8 +1,8 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
 	"github.com/spf13/cobra"
 	"os"
 	"time"
@@ -16,25 +16,25 @@ func timer(name string) func() {
 }
 
 var rootCmd = &cobra.Command{
-	Use:   "Scheduling",
-	Short: "Run the scheduling algorithm.",
-	Long:  `This is the entry point for the scheduling algorithm with a specified number of iterations.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		Scheduler := scheduler.NewScheduler()
-		defer timer("scheduling")()
-		Scheduler.Run(numIterations)
-	},
+	Use:           "Scheduling",
+	Short:         "Run the scheduling algorithm.",
+	Long:          `This is the entry point for the scheduling algorithm with a specified number of iterations.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	// Running without a subcommand schedules, like the run subcommand, for compatibility
+	RunE: runSchedule,
 }
 
-var numIterations int
-
 func init() {
-	rootCmd.PersistentFlags().IntVarP(&numIterations, "iterations", "n", 100, "Number of iterations to run the algorithm.")
+	addScheduleFlags(rootCmd.Flags())
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		// A failed validation has already printed its report
+		if !errors.Is(err, errValidationFailed) {
+			fmt.Println(err)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/slog"
+	"runtime"
+	"strings"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the scheduling algorithm.",
+	Long:  `Run the scheduling algorithm on jadata.csv and events.csv and write the results to the results and sections folders.`,
+	RunE:  runSchedule,
+}
+
+var numIterations int
+var numWorkers int
+var seed int64
+var manifestPath string
+var strategyName string
+var rankWeights string
+var unrequestedWeight float64
+var annealOptions scheduler.AnnealOptions
+var columnsPath string
+var aliasesPath string
+var duplicatePolicy string
+var gradesPath string
+var locksPath string
+var groupsPath string
+var apartPath string
+var balancePath string
+var slotsPath string
+var cancelUnderEnrolled bool
+var groupWeight float64
+
+func init() {
+	addScheduleFlags(runCmd.Flags())
+	rootCmd.AddCommand(runCmd)
+}
+
+// addScheduleFlags registers the scheduling flags, which the root command shares with the run subcommand
+func addScheduleFlags(flags *pflag.FlagSet) {
+	flags.IntVarP(&numIterations, "iterations", "n", 100, "Number of iterations to run the algorithm.")
+	flags.IntVarP(&numWorkers, "workers", "w", runtime.NumCPU(), "Number of iterations to run concurrently.")
+	flags.Int64Var(&seed, "seed", 0, "Seed for all randomness, so that a run can be reproduced. Random when not set.")
+	flags.StringVar(&manifestPath, "manifest", "", "Repeat the run recorded in this manifest file, whose options can't be given as flags as well.")
+	flags.StringVar(&rankWeights, "weights", imp.DefaultRankWeights.FormatRanks(), "Comma separated satisfaction cost of getting each ranked choice.")
+	flags.Float64Var(&unrequestedWeight, "unrequested-weight", imp.DefaultRankWeights.Unrequested, "Satisfaction cost of getting a course that wasn't requested.")
+	flags.Float64Var(&annealOptions.StartTemperature, "anneal-start-temperature", scheduler.DefaultAnnealOptions.StartTemperature, "Starting temperature of the annealing pass.")
+	flags.Float64Var(&annealOptions.EndTemperature, "anneal-end-temperature", scheduler.DefaultAnnealOptions.EndTemperature, "Final temperature of the annealing pass.")
+	flags.IntVar(&annealOptions.Steps, "anneal-steps", scheduler.DefaultAnnealOptions.Steps, "Number of moves the annealing pass cools over.")
+	flags.DurationVar(&annealOptions.Budget, "anneal-budget", scheduler.DefaultAnnealOptions.Budget, "How long the annealing pass may run.")
+	flags.Float64Var(&groupWeight, "group-weight", scheduler.DefaultGroupWeight, "Satisfaction cost of every student placed apart from the rest of their group.")
+	flags.BoolVar(&cancelUnderEnrolled, "cancel-under-enrolled", false, "Cancel courses that don't reach their minimum enrollment and place their students in their next choices.")
+	addDataFlags(flags)
+	flags.StringVarP(&strategyName, "strategy", "s", scheduler.DefaultStrategy, "Scheduling strategy to use ("+strings.Join(scheduler.StrategyNames(), ", ")+").")
+}
+
+func runSchedule(cmd *cobra.Command, args []string) error {
+	strategy, err := scheduler.LookupStrategy(strategyName)
+	if err != nil {
+		return err
+	}
+	weights, err := imp.ParseRankWeights(rankWeights, unrequestedWeight)
+	if err != nil {
+		return err
+	}
+	options, err := readDataOptions()
+	if err != nil {
+		return err
+	}
+	loader := data.NewDataLoaderWithOptions(options)
+	if err := loader.Check(); err != nil {
+		return err
+	}
+	Scheduler, err := scheduler.NewSchedulerWithData(loader)
+	if err != nil {
+		return err
+	}
+	Scheduler.Strategy = strategy
+	Scheduler.Weights = weights
+	Scheduler.Anneal = &annealOptions
+	Scheduler.Workers = numWorkers
+	Scheduler.CancelUnderEnrolled = cancelUnderEnrolled
+	Scheduler.GroupWeight = groupWeight
+	if cmd.Flags().Changed("seed") {
+		Scheduler.Seed = seed
+	}
+	if manifestPath != "" {
+		if err := checkManifestFlags(cmd); err != nil {
+			return err
+		}
+		manifest, err := scheduler.ReadManifest(manifestPath)
+		if err != nil {
+			return err
+		}
+		if numIterations, err = manifest.Apply(Scheduler); err != nil {
+			return err
+		}
+	}
+	defer timer("scheduling")()
+	schedule, err := Scheduler.RunContext(cmd.Context(), numIterations)
+	var apartErr *scheduler.KeptApartError
+	if errors.As(err, &apartErr) {
+		slog.Error("the keep-apart constraints can't be met", "detail", apartErr.Detail)
+	}
+	if err != nil {
+		return err
+	}
+	printSummary(Scheduler.Seed, schedule)
+	return nil
+}
+
+// manifestFlags are the flags whose values a manifest records and replaces
+var manifestFlags = []string{
+	"iterations", "workers", "seed", "strategy", "weights", "unrequested-weight", "group-weight", "cancel-under-enrolled",
+	"anneal-start-temperature", "anneal-end-temperature", "anneal-steps", "anneal-budget",
+}
+
+// checkManifestFlags refuses the flags a manifest would silently override
+func checkManifestFlags(cmd *cobra.Command) error {
+	var changed []string
+	for _, name := range manifestFlags {
+		if cmd.Flags().Changed(name) {
+			changed = append(changed, "--"+name)
+		}
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("%s can't be combined with --manifest, which repeats the options of the recorded run", strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// printSummary prints the seed and score of a run together with the cancellations and groups it reports
+func printSummary(seed int64, schedule *scheduler.Schedule) {
+	fmt.Println("Seed:", seed)
+	if schedule.Improved {
+		fmt.Println("Greedy schedule score:", schedule.GreedyScore)
+	}
+	for _, cancellation := range schedule.Cancelled {
+		fmt.Printf("Cancelled section %d of %s: %s\n", cancellation.Section, cancellation.Course, cancellation.Reason)
+	}
+	if len(schedule.Groups) > 0 {
+		honored := 0
+		for _, result := range schedule.Groups {
+			if result.Honored {
+				honored++
+			}
+		}
+		fmt.Printf("Honored %d of %d group requests\n", honored, len(schedule.Groups))
+	}
+	fmt.Println("Best schedule score:", schedule.Score)
+	if schedule.Optimal {
+		fmt.Println("The schedule is proven optimal.")
+	}
+}
+
+// addDataFlags registers the flags that change how the input files are read
+func addDataFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&columnsPath, "columns", "", "JSON file mapping the headers of jadata.csv to the request fields. Uses the Google Form headers when not set.")
+	flags.StringVar(&aliasesPath, "aliases", data.AliasesFile, "JSON file mapping requested course names to event names. Ignored when it doesn't exist.")
+	flags.StringVar(&duplicatePolicy, "duplicates", data.DuplicatesLatest, "What to do with students who submitted the form more than once: latest, first or reject.")
+	flags.StringVar(&gradesPath, "grades", data.GradesFile, "JSON file mapping grades to the tiers their students are scheduled in. Juniors, then sophomores, then freshmen when it doesn't exist.")
+	flags.StringVar(&locksPath, "locked", data.LocksFile, "CSV file of Email, Course and optional Section columns locking students into courses. Ignored when it doesn't exist.")
+	flags.StringVar(&groupsPath, "groups", data.GroupsFile, "CSV file of Email columns, and an optional Time Slot column, naming students who want to be placed together. Ignored when it doesn't exist.")
+	flags.StringVar(&apartPath, "apart", data.ApartFile, "Confidential CSV file of Email columns naming students who must never share a course. Ignored when it doesn't exist.")
+	flags.StringVar(&balancePath, "balance", data.BalanceFile, "JSON file of rules limiting how many students of a grade or request answer a section may hold. Ignored when it doesn't exist.")
+	flags.StringVar(&slotsPath, "slots", data.SlotsFile, "JSON file declaring the time slots and the slots the courses of several slots take up. A morning and an afternoon when it doesn't exist.")
+}
+
+// readDataOptions loads the column mapping, alias table, grade table, locked seats, group requests,
+// keep-apart constraints, balance rules and time slots the data flags name
+func readDataOptions() (data.Options, error) {
+	var options data.Options
+	var err error
+	if columnsPath != "" {
+		if options.Columns, err = data.ReadColumnMapping(columnsPath); err != nil {
+			return options, err
+		}
+	}
+	if options.Aliases, err = data.ReadAliases(aliasesPath); err != nil {
+		return options, err
+	}
+	if options.Duplicates, err = data.ParseDuplicatePolicy(duplicatePolicy); err != nil {
+		return options, err
+	}
+	if options.Grades, err = data.ReadGradeTable(gradesPath); err != nil {
+		return options, err
+	}
+	if options.Locks, err = data.ReadLocks(locksPath); err != nil {
+		return options, err
+	}
+	if options.Groups, err = data.ReadGroups(groupsPath); err != nil {
+		return options, err
+	}
+	if options.Apart, err = data.ReadKeepApart(apartPath); err != nil {
+		return options, err
+	}
+	options.ApartPath = apartPath
+	if options.Balance, err = data.ReadBalanceRules(balancePath); err != nil {
+		return options, err
+	}
+	if options.Slots, err = data.ReadSlotTable(slotsPath); err != nil {
+		return options, err
+	}
+	return options, nil
+}
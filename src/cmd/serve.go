@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"github.com/agavris/june-academy-go/src/server"
+	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the scheduling algorithm over HTTP.",
+	Long:  `Start an HTTP server exposing the /schedule endpoint, the /jobs API and uploads to /datasets. SIGINT or SIGTERM shuts it down gracefully once in-flight scheduling runs have finished.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		options, err := readDataOptions()
+		if err != nil {
+			return err
+		}
+		serverConfig.Data = options
+		return server.Serve(ctx, serverConfig)
+	},
+}
+
+var serverConfig server.Config
+
+func init() {
+	serveCmd.Flags().StringVarP(&serverConfig.Port, "port", "p", "8080", "Port to listen on.")
+	serveCmd.Flags().StringVar(&serverConfig.Addr, "addr", "", "Address to listen on. Listens on all interfaces when empty.")
+	serveCmd.Flags().DurationVar(&serverConfig.ReadTimeout, "read-timeout", 30*time.Second, "Maximum duration for reading a request.")
+	serveCmd.Flags().DurationVar(&serverConfig.WriteTimeout, "write-timeout", 10*time.Minute, "Maximum duration of a request, including the scheduling run.")
+	serveCmd.Flags().DurationVar(&serverConfig.ShutdownTimeout, "shutdown-timeout", 0, "How long to wait for in-flight runs on shutdown. Waits until they finish when zero.")
+	serveCmd.Flags().IntVar(&serverConfig.JobWorkers, "job-workers", 2, "Number of jobs run at the same time.")
+	serveCmd.Flags().IntVar(&serverConfig.JobQueueSize, "job-queue", 64, "Number of jobs that can wait for a worker before new ones are refused.")
+	serveCmd.Flags().DurationVar(&serverConfig.JobRetention, "job-retention", time.Hour, "How long the status and schedule of a finished job are kept. Keeps them until the server stops when zero.")
+	serveCmd.Flags().IntVar(&serverConfig.MaxFinishedJobs, "max-finished-jobs", 100, "Number of finished jobs kept, forgetting the oldest first. Unbounded when zero.")
+	serveCmd.Flags().DurationVar(&serverConfig.DatasetRetention, "dataset-retention", 24*time.Hour, "How long an uploaded dataset is kept after it was last used. Keeps it until the server stops when zero.")
+	serveCmd.Flags().IntVar(&serverConfig.MaxDatasets, "max-datasets", 20, "Number of uploaded datasets kept, forgetting the least recently used first. Unbounded when zero.")
+	addDataFlags(serveCmd.Flags())
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var validateCmd = &cobra.Command{
+	Use:           "validate",
+	Short:         "Check jadata.csv and events.csv for problems.",
+	Long:          `Load jadata.csv and events.csv and report every problem found in them. Exits with a non-zero status when there are errors.`,
+	RunE:          runValidate,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var validateFormat string
+
+// errValidationFailed reports that the input has errors, which the printed report already lists
+var errValidationFailed = errors.New("the input files have errors")
+
+func init() {
+	validateCmd.Flags().StringVar(&validateFormat, "format", "text", "Output format, text or json.")
+	addDataFlags(validateCmd.Flags())
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if validateFormat != "text" && validateFormat != "json" {
+		return fmt.Errorf("unknown format %q, expected text or json", validateFormat)
+	}
+	options, err := readDataOptions()
+	if err != nil {
+		return err
+	}
+	requests, err := os.ReadFile(data.RequestsFile)
+	if err != nil {
+		return err
+	}
+	events, err := os.ReadFile(data.EventsFile)
+	if err != nil {
+		return err
+	}
+
+	report := data.Validate(requests, events, options)
+	out := cmd.OutOrStdout()
+	if validateFormat == "json" {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(output))
+	} else {
+		for _, problem := range report.Problems {
+			fmt.Fprintln(out, problem)
+		}
+		fmt.Fprintf(out, "Found %d errors and %d warnings.\n", report.Errors, report.Warnings)
+	}
+
+	if report.Errors > 0 {
+		return errValidationFailed
+	}
+	return nil
+}
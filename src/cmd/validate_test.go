@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+func TestValidateFailsOnErrorsAfterTheReport(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	requests := "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+		"AM Course - 1st Choice. (Drop down option)\n" +
+		"a@x.org,A,A,Junior,X\n"
+	tests := []struct {
+		name   string
+		events string
+		want   error
+		report string
+	}{
+		{"valid input", "Name,Max Students,Time Slot\nX,1,AM\n", nil, "Found 0 errors and 0 warnings."},
+		{"unknown course", "Name,Max Students,Time Slot\nY,1,AM\n", errValidationFailed, "Found 1 errors and 1 warnings."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := os.WriteFile(data.RequestsFile, []byte(requests), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(data.EventsFile, []byte(test.events), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			validateCmd.SetOut(&out)
+			defer validateCmd.SetOut(nil)
+
+			if err := runValidate(validateCmd, nil); !errors.Is(err, test.want) {
+				t.Errorf("runValidate() = %v, want %v", err, test.want)
+			}
+			if !strings.Contains(out.String(), test.report) {
+				t.Errorf("printed %q, want the report to end with %q", out.String(), test.report)
+			}
+		})
+	}
+}
@@ -1,21 +1,63 @@
 package imp
 
+import "strings"
+
 type Course struct {
 	CourseName string
 	TimeSlot   string
+	// Slots lists the time slots of the day the course takes up, in the order they take place. It only
+	// differs from TimeSlot for courses that take up more than one slot.
+	Slots []string
+	// Grades lists the grades that may take the course, every grade when empty
+	Grades []string
+	// Section numbers the section of the course, from one, when the course stands for one of its sections
+	Section int
 }
 
 func NewCourse(courseName string, timeSlot string) *Course {
 	return &Course{
 		CourseName: courseName,
 		TimeSlot:   timeSlot,
+		Slots:      []string{timeSlot},
 	}
 }
 
 func (c *Course) DeepCopy() Course {
-	return *NewCourse(c.CourseName, c.TimeSlot)
+	course := *NewCourse(c.CourseName, c.TimeSlot)
+	course.Slots = append([]string(nil), c.Slots...)
+	course.Grades = append([]string(nil), c.Grades...)
+	course.Section = c.Section
+	return course
 }
 
 func (c *Course) Equals(other *Course) bool {
 	return c.CourseName == other.CourseName && c.TimeSlot == other.TimeSlot
 }
+
+// Occupies reports whether the course takes up the time slot
+func (c *Course) Occupies(timeSlot string) bool {
+	for _, slot := range c.Slots {
+		if slot == timeSlot {
+			return true
+		}
+	}
+	return false
+}
+
+// MultiSlot reports whether the course takes up more than one time slot
+func (c *Course) MultiSlot() bool {
+	return len(c.Slots) > 1
+}
+
+// IsEligible reports whether students of the grade may take the course
+func (c *Course) IsEligible(grade string) bool {
+	if len(c.Grades) == 0 {
+		return true
+	}
+	for _, eligible := range c.Grades {
+		if strings.EqualFold(strings.TrimSpace(eligible), strings.TrimSpace(grade)) {
+			return true
+		}
+	}
+	return false
+}
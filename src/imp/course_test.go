@@ -0,0 +1,24 @@
+package imp
+
+import "testing"
+
+func TestIsEligible(t *testing.T) {
+	tests := []struct {
+		grades []string
+		grade  string
+		want   bool
+	}{
+		{nil, "Freshman", true},
+		{[]string{"Junior"}, "Junior", true},
+		{[]string{"Junior", "Sophomore"}, " sophomore", true},
+		{[]string{" Junior "}, "junior", true},
+		{[]string{"Junior"}, "Freshman", false},
+		{[]string{"Junior"}, "", false},
+	}
+	for _, test := range tests {
+		course := &Course{CourseName: "Robotics", Grades: test.grades}
+		if got := course.IsEligible(test.grade); got != test.want {
+			t.Errorf("a course open to %q IsEligible(%q) = %v, want %v", test.grades, test.grade, got, test.want)
+		}
+	}
+}
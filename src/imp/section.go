@@ -2,37 +2,24 @@ package imp
 
 import (
 	"fmt"
-	"github.com/agavris/june-academy-go/src/algorithm/utils/events"
 )
 
 type Section struct {
 	Course      *Course
 	MaxStudents int
+	// MinStudents is the enrollment the section needs to run, no minimum when zero
+	MinStudents int
+	Instructor  string
+	Room        string
 	Students    []*Student
 }
 
 func NewSection(course *Course, maxStudents int) *Section {
-	sec := &Section{
+	return &Section{
 		Course:      course,
 		MaxStudents: maxStudents,
 		Students:    make([]*Student, 0),
 	}
-
-	var err error
-	courses, err := events.ReadCourses("events.csv")
-	coursesToMax := events.MapCoursesToMaxStudents(courses)
-	if err != nil {
-		fmt.Println("Error loading events from CSV file: ", err)
-	}
-	if max, ok := coursesToMax[course.CourseName]; ok {
-		sec.MaxStudents = max
-	} else {
-		// Handle the case where the course name is not found in the map
-		fmt.Println("Course name not found in events map. Please check to make sure the names match in both your events.csv file and your jadata.csv file!")
-		panic(course.CourseName)
-	}
-
-	return sec
 }
 
 func (s *Section) AddStudent(student *Student) {
@@ -61,17 +48,40 @@ func (s *Section) DeepCopy() *Section {
 	return &Section{
 		Course:      s.Course,
 		MaxStudents: s.MaxStudents,
+		MinStudents: s.MinStudents,
+		Instructor:  s.Instructor,
+		Room:        s.Room,
 		Students:    studentArray,
 	}
 }
 
+// UnderEnrolled reports whether fewer students are enrolled than the section needs to run
+func (s *Section) UnderEnrolled() bool {
+	return len(s.Students) < s.MinStudents
+}
+
+// Fill returns the share of the section's capacity that is taken
+func (s *Section) Fill() float64 {
+	if s.MaxStudents == 0 {
+		return 1
+	}
+	return float64(len(s.Students)) / float64(s.MaxStudents)
+}
+
+// EmptyCopy returns a section of the same course and size as s without students
+func (s *Section) EmptyCopy() *Section {
+	copied := *s
+	copied.Students = make([]*Student, 0)
+	return &copied
+}
+
 func (s *Section) String() string {
 	//return fmt.Sprintf("Section: %s, Timeslot: %s, Students: %d, List: %s", s.Course.CourseName, s.Course.TimeSlot, len(s.Students), s.Students)
 	return fmt.Sprintf("Section: %s, Students: %d", s.Course.CourseName, len(s.Students))
 }
 
 func (s *Section) Equals(other *Section) bool {
-	return s.Course.Equals(other.Course)
+	return s.Course.Equals(other.Course) && s.Course.Section == other.Course.Section
 }
 
 func (s *Section) NotEquals(other *Section) bool {
@@ -3,12 +3,35 @@ package imp
 import (
 	"fmt"
 	"github.com/agavris/june-academy-go/src/algorithm"
+	"sort"
 )
 
-type EnrolledCourses struct {
-	AMCourse      Course
-	PMCourse      Course
-	FullDayCourse Course
+// EnrolledCourses maps every time slot a student is seated in to their course, a course that takes up
+// several slots is under each of them
+type EnrolledCourses map[string]Course
+
+// Courses returns every course of the enrollment once, ordered by the name of the first time slot it is under
+func (e EnrolledCourses) Courses() []Course {
+	slots := make([]string, 0, len(e))
+	for slot := range e {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+	type section struct {
+		course string
+		number int
+	}
+	var courses []Course
+	seen := make(map[section]bool, len(e))
+	for _, slot := range slots {
+		course := e[slot]
+		key := section{course: course.CourseName, number: course.Section}
+		if course.CourseName != "" && !seen[key] {
+			seen[key] = true
+			courses = append(courses, course)
+		}
+	}
+	return courses
 }
 
 type Student struct {
@@ -17,8 +40,12 @@ type Student struct {
 	StudentEmail     string
 	StudentPriority  int
 	Grade            string
-	EnrolledCourses  *EnrolledCourses
+	EnrolledCourses  EnrolledCourses
 	RequestedCourses *algorithm.Request
+	Weights          *RankWeights `json:"-"`
+	// TimeSlots lists the time slots the student should have a course in, every one without a course
+	// adds the unseated weight to the satisfaction score
+	TimeSlots []string `json:"-"`
 }
 
 func NewStudent(firstName string, lastName string, email string, studentPriority int, requestedCourses *algorithm.Request, grade string) *Student {
@@ -27,87 +54,105 @@ func NewStudent(firstName string, lastName string, email string, studentPriority
 		StudentLastName:  lastName,
 		StudentEmail:     email,
 		StudentPriority:  studentPriority,
-		EnrolledCourses:  &EnrolledCourses{},
+		EnrolledCourses:  make(EnrolledCourses),
 		RequestedCourses: requestedCourses,
 		Grade:            grade,
 	}
 }
 
 func (s *Student) AddEnrolledCourse(course *Course) {
-	if course.TimeSlot == "AM" {
-		s.EnrolledCourses.AMCourse = *course
-	} else if course.TimeSlot == "PM" {
-		s.EnrolledCourses.PMCourse = *course
-	} else {
-		s.EnrolledCourses.FullDayCourse = *course
+	for _, slot := range course.Slots {
+		s.EnrolledCourses[slot] = *course
 	}
 }
 
 func (s *Student) RemoveEnrolledCourse(course *Course) {
-	if course.TimeSlot == "AM" {
-		s.EnrolledCourses.AMCourse = Course{}
-	} else if course.TimeSlot == "PM" {
-		s.EnrolledCourses.PMCourse = Course{}
-	} else {
-		s.EnrolledCourses.FullDayCourse = Course{}
+	for _, slot := range course.Slots {
+		delete(s.EnrolledCourses, slot)
 	}
 }
 
 func (s *Student) UnrollEverything() {
-	s.EnrolledCourses = &EnrolledCourses{}
+	s.EnrolledCourses = make(EnrolledCourses)
 }
 
-func (s *Student) SatisfactionScore() float64 {
-	score := 0.0
-
-	// Function to check if course is in the slice
-	isInCourses := func(courseName string, courses []string) bool {
-		for _, c := range courses {
-			if c == courseName {
-				return true
-			}
+// Free reports whether the student has no course yet in any time slot the course takes up
+func (s *Student) Free(course *Course) bool {
+	for _, slot := range course.Slots {
+		if s.EnrolledCourses[slot].CourseName != "" {
+			return false
 		}
-		return false
 	}
+	return true
+}
 
-	// Check FullDayCourse
-	fullDayCourse := s.EnrolledCourses.FullDayCourse
-	if fullDayCourse.CourseName != "" {
-		if !isInCourses(fullDayCourse.CourseName, s.RequestedCourses.GetAMCourses()) {
-			score += 1
+// UnseatedSlots returns the time slots the student should have a course in but has none
+func (s *Student) UnseatedSlots() []string {
+	var unseated []string
+	for _, slot := range s.TimeSlots {
+		if s.EnrolledCourses[slot].CourseName == "" {
+			unseated = append(unseated, slot)
 		}
-		return score // Early return if full day course is present
 	}
+	return unseated
+}
 
-	// Check AMCouse and PMCouse
-	amCourse := s.EnrolledCourses.AMCourse
-	if amCourse.CourseName != "" && !isInCourses(amCourse.CourseName, s.RequestedCourses.GetAMCourses()) {
-		score += 0.5
-	}
-	pmCourse := s.EnrolledCourses.PMCourse
-	if pmCourse.CourseName != "" && !isInCourses(pmCourse.CourseName, s.RequestedCourses.GetPMCourses()) {
-		score += 0.5
+// SatisfactionScore returns the cost of the student's courses and of the time slots they have no course in
+func (s *Student) SatisfactionScore() float64 {
+	score := float64(len(s.UnseatedSlots())) * s.weights().Unseated()
+	for _, course := range s.EnrolledCourses.Courses() {
+		score += s.CourseCost(&course)
 	}
-
 	return score
 }
 
-func (s *Student) CopyEnrolledCourses() *EnrolledCourses {
-	if s.EnrolledCourses == nil {
-		return &EnrolledCourses{}
+func (s *Student) weights() *RankWeights {
+	if s.Weights == nil {
+		return &DefaultRankWeights
 	}
-	return &EnrolledCourses{
-		AMCourse:      s.EnrolledCourses.AMCourse.DeepCopy(),
-		PMCourse:      s.EnrolledCourses.PMCourse.DeepCopy(),
-		FullDayCourse: s.EnrolledCourses.FullDayCourse.DeepCopy(),
+	return s.Weights
+}
+
+// CourseCost returns how much enrolling in the given course adds to the student's satisfaction score
+func (s *Student) CourseCost(course *Course) float64 {
+	// Function to find the rank of the course in the slice
+	rankInCourses := func(courseName string, courses []string) int {
+		for i, c := range courses {
+			if c == courseName {
+				return i
+			}
+		}
+		return -1
 	}
+
+	weights := s.weights()
+
+	// A course that takes up several time slots is requested among the choices of its first one, and
+	// costs its rank in every slot it takes up
+	if len(course.Slots) == 0 {
+		return weights.Cost(rankInCourses(course.CourseName, s.RequestedCourses.GetCourses(course.TimeSlot)))
+	}
+	return float64(len(course.Slots)) * weights.Cost(rankInCourses(course.CourseName, s.RequestedCourses.GetCourses(course.Slots[0])))
+}
+
+func (s *Student) CopyEnrolledCourses() EnrolledCourses {
+	enrolled := make(EnrolledCourses, len(s.EnrolledCourses))
+	for slot, course := range s.EnrolledCourses {
+		enrolled[slot] = course.DeepCopy()
+	}
+	return enrolled
 }
 
 func (s *Student) CopyRequestedCourses() *algorithm.Request {
+	choices := make(map[string][]string, len(s.RequestedCourses.Choices))
+	for slot, courses := range s.RequestedCourses.Choices {
+		choices[slot] = append([]string(nil), courses...)
+	}
 	return &algorithm.Request{
-		Grade: s.RequestedCourses.Grade,
-		AMFD1: s.RequestedCourses.AMFD1, AMFD2: s.RequestedCourses.AMFD2, AMFD3: s.RequestedCourses.AMFD3, AMFD4: s.RequestedCourses.AMFD4, AMFD5: s.RequestedCourses.AMFD5,
-		PM1: s.RequestedCourses.PM1, PM2: s.RequestedCourses.PM2, PM3: s.RequestedCourses.PM3, PM4: s.RequestedCourses.PM4, PM5: s.RequestedCourses.PM5,
+		Grade:   s.RequestedCourses.Grade,
+		Choices: choices,
+		// The attributes are never changed, so the copy shares them
+		Attributes: s.RequestedCourses.Attributes,
 	}
 }
 
@@ -120,6 +165,8 @@ func (s *Student) DeepCopy() *Student {
 		EnrolledCourses:  s.CopyEnrolledCourses(),
 		RequestedCourses: s.CopyRequestedCourses(),
 		Grade:            s.Grade,
+		Weights:          s.Weights,
+		TimeSlots:        s.TimeSlots,
 	}
 }
 
@@ -129,5 +176,4 @@ func (s *Student) Equals(other *Student) bool {
 
 func (s *Student) String() string {
 	return fmt.Sprintf("%s %s", s.StudentFirstName, s.StudentLastName)
-	//return fmt.Sprintf("%s %s, %s, AM: %s, PM: %s, FD: %s, SS: %f", s.StudentFirstName, s.StudentLastName, s.Grade, s.EnrolledCourses.AMCourse.CourseName, s.EnrolledCourses.PMCourse.CourseName, s.EnrolledCourses.FullDayCourse.CourseName, s.SatisfactionScore())
 }
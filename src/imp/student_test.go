@@ -0,0 +1,45 @@
+package imp
+
+import (
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm"
+)
+
+func TestSatisfactionScoreChargesUnseatedSlots(t *testing.T) {
+	request := &algorithm.Request{Choices: map[string][]string{"AM": {"X", "Y"}, "PM": {"Z"}}}
+	am := &Course{CourseName: "Y", TimeSlot: "AM", Slots: []string{"AM"}}
+	pm := &Course{CourseName: "Z", TimeSlot: "PM", Slots: []string{"PM"}}
+	fullDay := &Course{CourseName: "X", TimeSlot: "FullDay", Slots: []string{"AM", "PM"}}
+	unseated := DefaultRankWeights.Unseated()
+
+	tests := []struct {
+		name    string
+		courses []*Course
+		want    float64
+	}{
+		{"every slot seated", []*Course{am, pm}, 1},
+		{"one slot unseated", []*Course{am}, 1 + unseated},
+		{"no course", nil, 2 * unseated},
+		{"full day course", []*Course{fullDay}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			student := NewStudent("F", "L", "f@x.org", 1, request, "Junior")
+			student.TimeSlots = []string{"AM", "PM"}
+			for _, course := range test.courses {
+				student.AddEnrolledCourse(course)
+			}
+			if got := student.SatisfactionScore(); got != test.want {
+				t.Errorf("SatisfactionScore() = %g, want %g", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUnseatedOutweighsAnyCourse(t *testing.T) {
+	weights := RankWeights{Ranks: []float64{0, 3, 50}, Unrequested: 20}
+	if unseated := weights.Unseated(); unseated <= 2*50 {
+		t.Errorf("Unseated() = %g, want more than a full day course of the last rank", unseated)
+	}
+}
@@ -0,0 +1,94 @@
+package imp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RankWeights is the cost a student adds to the satisfaction score for each enrollment,
+// indexed by the rank of the course among their choices. A course taking up several time
+// slots costs its weight in each of them.
+type RankWeights struct {
+	Ranks       []float64 `json:"ranks"`
+	Unrequested float64   `json:"unrequested"`
+}
+
+// unseatedFactor scales the largest weight into the cost of a time slot a student has no course in
+const unseatedFactor = 100
+
+var DefaultRankWeights = RankWeights{
+	Ranks:       []float64{0, 1, 2, 4, 8},
+	Unrequested: 16,
+}
+
+// ParseRankWeights builds rank weights from a comma separated list such as "0,1,2,4,8"
+func ParseRankWeights(ranks string, unrequested float64) (*RankWeights, error) {
+	weights := &RankWeights{Unrequested: unrequested}
+	for _, field := range strings.Split(ranks, ",") {
+		weight, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank weight %q: %w", field, err)
+		}
+		weights.Ranks = append(weights.Ranks, weight)
+	}
+	if len(weights.Ranks) == 0 {
+		return nil, errors.New("at least one rank weight is required")
+	}
+	return weights, nil
+}
+
+// Cost returns the weight of the choice with the given zero based rank, or the unrequested
+// weight for a negative rank. Ranks past the end of the table reuse its last weight.
+func (w *RankWeights) Cost(rank int) float64 {
+	if rank < 0 {
+		return w.Unrequested
+	}
+	if len(w.Ranks) == 0 {
+		return 0
+	}
+	if rank >= len(w.Ranks) {
+		return w.Ranks[len(w.Ranks)-1]
+	}
+	return w.Ranks[rank]
+}
+
+// Unseated returns what every time slot a student is left without a course in adds to the satisfaction
+// score. It outweighs the weight of any course, so every strategy seats as many students as it can first.
+func (w *RankWeights) Unseated() float64 {
+	largest := math.Max(w.Unrequested, 1)
+	for _, weight := range w.Ranks {
+		largest = math.Max(largest, weight)
+	}
+	return unseatedFactor * largest
+}
+
+func (w *RankWeights) Equal(other *RankWeights) bool {
+	if w == nil || other == nil {
+		return w == other
+	}
+	if w.Unrequested != other.Unrequested || len(w.Ranks) != len(other.Ranks) {
+		return false
+	}
+	for i := range w.Ranks {
+		if w.Ranks[i] != other.Ranks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *RankWeights) String() string {
+	return fmt.Sprintf("%s (unrequested %s)", w.FormatRanks(), strconv.FormatFloat(w.Unrequested, 'g', -1, 64))
+}
+
+// FormatRanks returns the rank weights as the comma separated list ParseRankWeights reads
+func (w *RankWeights) FormatRanks() string {
+	ranks := make([]string, len(w.Ranks))
+	for i, weight := range w.Ranks {
+		ranks[i] = strconv.FormatFloat(weight, 'g', -1, 64)
+	}
+	return strings.Join(ranks, ",")
+}
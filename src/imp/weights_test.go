@@ -0,0 +1,12 @@
+package imp
+
+import "testing"
+
+func TestFormatRanksIsReadBack(t *testing.T) {
+	for _, weights := range []RankWeights{DefaultRankWeights, {Ranks: []float64{0, 0.5, 10}, Unrequested: 3}} {
+		parsed, err := ParseRankWeights(weights.FormatRanks(), weights.Unrequested)
+		if err != nil || !parsed.Equal(&weights) {
+			t.Errorf("ParseRankWeights(%q) = %v, %v, want %v", weights.FormatRanks(), parsed, err, &weights)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package datasets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+// Dataset is a pair of uploaded input files, loaded and ready to be scheduled
+type Dataset struct {
+	ID       string   `json:"id"`
+	Students int      `json:"students"`
+	Courses  int      `json:"courses"`
+	Warnings []string `json:"warnings"`
+	// Duplicates lists the students who submitted the form more than once and the lines that were removed
+	Duplicates []data.Duplicate `json:"duplicates"`
+	CreatedAt  time.Time        `json:"createdAt"`
+
+	// Scheduler holds the loaded data, runs on the dataset clone it
+	Scheduler *scheduler.Scheduler `json:"-"`
+
+	// usedAt is when the dataset was last uploaded or looked up
+	usedAt time.Time
+}
+
+// Store keeps the uploaded datasets in memory
+type Store struct {
+	mu       sync.Mutex
+	datasets map[string]*Dataset
+	// Datasets are forgotten retention after they were last used, and the least recently used ones once
+	// more than maxDatasets are stored. Zero keeps them for as long, or as many, as there are.
+	retention   time.Duration
+	maxDatasets int
+	now         func() time.Time
+}
+
+// NewStore returns a store keeping datasets for retention after they were last used and at most
+// maxDatasets of them, zero leaving either unbounded
+func NewStore(retention time.Duration, maxDatasets int) *Store {
+	return &Store{
+		datasets:    make(map[string]*Dataset),
+		retention:   retention,
+		maxDatasets: maxDatasets,
+		now:         time.Now,
+	}
+}
+
+// Add stores the loaded data. Its ID is derived from the contents of the input files, the alias table
+// and the duplicates policy, so uploading the same files again returns the dataset that is already stored.
+// It fails when the data can't be scheduled.
+func (s *Store) Add(loader *data.DataLoader) (*Dataset, error) {
+	names := make([]string, 0, len(loader.Inputs))
+	for name := range loader.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	digest := sha256.New()
+	for _, name := range names {
+		digest.Write([]byte(name + "=" + loader.Inputs[name] + "\n"))
+	}
+	digest.Write([]byte("duplicates=" + loader.Options.Duplicates))
+	id := hex.EncodeToString(digest.Sum(nil)[:8])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+	if dataset, ok := s.datasets[id]; ok {
+		dataset.usedAt = s.now()
+		return dataset, nil
+	}
+	base, err := scheduler.NewSchedulerWithData(loader)
+	if err != nil {
+		return nil, err
+	}
+	warnings := loader.Warnings
+	if warnings == nil {
+		warnings = []string{}
+	}
+	duplicates := loader.Duplicates
+	if duplicates == nil {
+		duplicates = []data.Duplicate{}
+	}
+	dataset := &Dataset{
+		ID:         id,
+		Duplicates: duplicates,
+		Students:   len(loader.Students),
+		Courses:    len(loader.Courses),
+		Warnings:   warnings,
+		CreatedAt:  s.now(),
+		Scheduler:  base,
+		usedAt:     s.now(),
+	}
+	s.datasets[id] = dataset
+	s.evict()
+	return dataset, nil
+}
+
+// Get returns the dataset with the given ID, unless it doesn't exist or was forgotten
+func (s *Store) Get(id string) (*Dataset, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+	dataset, ok := s.datasets[id]
+	if ok {
+		dataset.usedAt = s.now()
+	}
+	return dataset, ok
+}
+
+// Delete forgets the dataset with the given ID, reporting whether it was stored. Runs already started on it
+// keep their own copy of the data.
+func (s *Store) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.datasets[id]
+	delete(s.datasets, id)
+	return ok
+}
+
+// evict forgets the datasets unused for longer than the retention, then the least recently used ones
+// over the cap. The caller holds s.mu.
+func (s *Store) evict() {
+	now := s.now()
+	if s.retention > 0 {
+		for id, dataset := range s.datasets {
+			if now.Sub(dataset.usedAt) > s.retention {
+				delete(s.datasets, id)
+			}
+		}
+	}
+	if s.maxDatasets <= 0 || len(s.datasets) <= s.maxDatasets {
+		return
+	}
+	stored := make([]*Dataset, 0, len(s.datasets))
+	for _, dataset := range s.datasets {
+		stored = append(stored, dataset)
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].usedAt.Before(stored[j].usedAt)
+	})
+	for _, dataset := range stored[:len(stored)-s.maxDatasets] {
+		delete(s.datasets, dataset.ID)
+	}
+}
@@ -0,0 +1,71 @@
+package datasets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+const headers = "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+	"AM Course - 1st Choice. (Drop down option)\n"
+
+// add stores the data of a single student requesting X, distinct for every email
+func add(t *testing.T, store *Store, email string) *Dataset {
+	t.Helper()
+	loader, err := data.Load([]byte(headers+email+",A,A,Junior,X\n"), []byte("Name,Max Students,Time Slot\nX,1,AM\n"), data.Options{})
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	dataset, err := store.Add(loader)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	return dataset
+}
+
+func TestStoreForgetsDatasetsUnusedForTheRetention(t *testing.T) {
+	store := NewStore(time.Hour, 0)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	used := add(t, store, "a@x.org")
+	if !used.CreatedAt.Equal(now) {
+		t.Errorf("CreatedAt = %v, want the store's time %v", used.CreatedAt, now)
+	}
+	unused := add(t, store, "b@x.org")
+	now = now.Add(45 * time.Minute)
+	if _, ok := store.Get(used.ID); !ok {
+		t.Fatal("a dataset used within the retention was forgotten")
+	}
+	now = now.Add(45 * time.Minute)
+	if _, ok := store.Get(unused.ID); ok {
+		t.Error("a dataset unused for longer than the retention is still kept")
+	}
+	if _, ok := store.Get(used.ID); !ok {
+		t.Error("a dataset used within the retention was forgotten")
+	}
+}
+
+func TestStoreKeepsTheRecentlyUsedDatasets(t *testing.T) {
+	store := NewStore(0, 2)
+	now := time.Now()
+	store.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	first := add(t, store, "a@x.org")
+	second := add(t, store, "b@x.org")
+	store.Get(first.ID)
+	third := add(t, store, "c@x.org")
+
+	if _, ok := store.Get(second.ID); ok {
+		t.Error("the least recently used dataset is still kept over the cap")
+	}
+	for _, dataset := range []*Dataset{first, third} {
+		if _, ok := store.Get(dataset.ID); !ok {
+			t.Errorf("dataset %s was forgotten, want the 2 most recently used kept", dataset.ID)
+		}
+	}
+}
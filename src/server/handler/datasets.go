@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/server/datasets"
+	"github.com/gorilla/mux"
+	"golang.org/x/exp/slog"
+)
+
+const maxUploadSize = 32 << 20
+
+// DatasetsHandler accepts uploaded input files under /datasets
+type DatasetsHandler struct {
+	Datasets *datasets.Store
+}
+
+func NewDatasetsHandler(store *datasets.Store) *DatasetsHandler {
+	return &DatasetsHandler{
+		Datasets: store,
+	}
+}
+
+// Create loads the requests and events files of a multipart form, reading them with the optional columns
+// mapping, aliases, grades, locked, groups, apart, balance and slots files and duplicates policy, and answers with the dataset's ID and warnings
+func (h *DatasetsHandler) Create(writer http.ResponseWriter, request *http.Request) {
+	request.Body = http.MaxBytesReader(writer, request.Body, maxUploadSize)
+	if err := request.ParseMultipartForm(maxUploadSize); err != nil {
+		http.Error(writer, "Invalid multipart form: "+err.Error(), http.StatusBadRequest)
+		slog.Error("failed to parse multipart form", "err", err)
+		return
+	}
+	// The parts that didn't fit in memory are kept in temporary files until the form is removed
+	defer request.MultipartForm.RemoveAll()
+
+	requests, err := readUpload(request, "requests")
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		slog.Error("failed to read requests upload", "err", err)
+		return
+	}
+	events, err := readUpload(request, "events")
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		slog.Error("failed to read events upload", "err", err)
+		return
+	}
+
+	options, err := readOptions(request)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		slog.Error("failed to read data options", "err", err)
+		return
+	}
+
+	loader, err := data.Load(requests, events, options)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		slog.Error("failed to load dataset", "err", err)
+		return
+	}
+
+	dataset, err := h.Datasets.Add(loader)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		slog.Error("failed to load dataset", "err", err)
+		return
+	}
+	writer.Header().Set("Location", "/datasets/"+dataset.ID)
+	writeJSON(writer, http.StatusCreated, dataset)
+}
+
+// Get describes an uploaded dataset
+func (h *DatasetsHandler) Get(writer http.ResponseWriter, request *http.Request) {
+	dataset, ok := h.Datasets.Get(mux.Vars(request)["id"])
+	if !ok {
+		http.Error(writer, "Dataset not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(writer, http.StatusOK, dataset)
+}
+
+// Delete forgets an uploaded dataset
+func (h *DatasetsHandler) Delete(writer http.ResponseWriter, request *http.Request) {
+	if !h.Datasets.Delete(mux.Vars(request)["id"]) {
+		http.Error(writer, "Dataset not found", http.StatusNotFound)
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func readUpload(request *http.Request, field string) ([]byte, error) {
+	contents, err := readOptionalUpload(request, field)
+	if err == nil && contents == nil {
+		err = fmt.Errorf("missing %s file: %w", field, http.ErrMissingFile)
+	}
+	return contents, err
+}
+
+// readOptions reads the optional column mapping and alias table uploads and the duplicates policy
+func readOptions(request *http.Request) (data.Options, error) {
+	var options data.Options
+	var err error
+	if options.Duplicates, err = data.ParseDuplicatePolicy(request.FormValue("duplicates")); err != nil {
+		return options, err
+	}
+	if contents, err := readOptionalUpload(request, "columns"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Columns, err = data.ParseColumnMapping(contents); err != nil {
+			return options, err
+		}
+	}
+	if contents, err := readOptionalUpload(request, "aliases"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Aliases, err = data.ParseAliases(contents); err != nil {
+			return options, err
+		}
+	}
+	if contents, err := readOptionalUpload(request, "grades"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Grades, err = data.ParseGradeTable(contents); err != nil {
+			return options, err
+		}
+	}
+	if contents, err := readOptionalUpload(request, "locked"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Locks, err = data.ParseLocks(contents); err != nil {
+			return options, err
+		}
+	}
+	if contents, err := readOptionalUpload(request, "groups"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Groups, err = data.ParseGroups(contents); err != nil {
+			return options, err
+		}
+	}
+	if contents, err := readOptionalUpload(request, "apart"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Apart, err = data.ParseKeepApart(contents); err != nil {
+			return options, err
+		}
+	}
+	if contents, err := readOptionalUpload(request, "balance"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Balance, err = data.ParseBalanceRules(contents); err != nil {
+			return options, err
+		}
+	}
+	if contents, err := readOptionalUpload(request, "slots"); err != nil {
+		return options, err
+	} else if contents != nil {
+		if options.Slots, err = data.ParseSlotTable(contents); err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
+// readOptionalUpload returns the contents of an uploaded file, or nil when the form has none for the field
+func readOptionalUpload(request *http.Request, field string) ([]byte, error) {
+	file, _, err := request.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s file: %w", field, err)
+	}
+	defer file.Close()
+	contents, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s file: %w", field, err)
+	}
+	return contents, nil
+}
+
+// datasetScheduler returns the scheduler a run should clone: the one of the dataset parameter when
+// it is given, otherwise the one loaded from the working directory
+func datasetScheduler(request *http.Request, base *scheduler.Scheduler, store *datasets.Store) (*scheduler.Scheduler, error) {
+	id := request.FormValue("dataset")
+	if id == "" {
+		return base, nil
+	}
+	dataset, ok := store.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("dataset %s not found", id)
+	}
+	return dataset.Scheduler, nil
+}
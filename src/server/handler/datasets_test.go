@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/server/datasets"
+	"github.com/gorilla/mux"
+)
+
+func newDatasetsServer() *httptest.Server {
+	datasetsHandler := NewDatasetsHandler(datasets.NewStore(0, 0))
+	router := mux.NewRouter()
+	router.HandleFunc("/datasets", datasetsHandler.Create).Methods(http.MethodPost)
+	router.HandleFunc("/datasets/{id}", datasetsHandler.Get).Methods(http.MethodGet)
+	router.HandleFunc("/datasets/{id}", datasetsHandler.Delete).Methods(http.MethodDelete)
+	return httptest.NewServer(router)
+}
+
+// upload posts the files as a multipart form to /datasets
+func upload(t *testing.T, url string, files map[string]string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for field, contents := range files {
+		part, err := form.CreateFormFile(field, field+".csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(contents))
+	}
+	form.Close()
+	response, err := http.Post(url+"/datasets", form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func send(t *testing.T, method, url string) int {
+	t.Helper()
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+func TestDeleteDataset(t *testing.T) {
+	server := newDatasetsServer()
+	defer server.Close()
+
+	response := upload(t, server.URL, map[string]string{"requests": requests, "events": events})
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("uploading answered %s", response.Status)
+	}
+	var dataset datasets.Dataset
+	if err := json.NewDecoder(response.Body).Decode(&dataset); err != nil {
+		t.Fatal(err)
+	}
+
+	url := server.URL + "/datasets/" + dataset.ID
+	steps := []struct {
+		method string
+		want   int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodDelete, http.StatusNoContent},
+		{http.MethodGet, http.StatusNotFound},
+		{http.MethodDelete, http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if got := send(t, step.method, url); got != step.want {
+			t.Errorf("%s %s answered %d, want %d", step.method, url, got, step.want)
+		}
+	}
+}
+
+func TestUploadLargerThanTheLimitIsRefused(t *testing.T) {
+	server := newDatasetsServer()
+	defer server.Close()
+
+	response := upload(t, server.URL, map[string]string{"requests": strings.Repeat("x", maxUploadSize), "events": events})
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("uploading more than %d bytes answered %s, want 400", maxUploadSize, response.Status)
+	}
+}
+
+func TestUploadWithoutEventsIsRefused(t *testing.T) {
+	server := newDatasetsServer()
+	defer server.Close()
+
+	response := upload(t, server.URL, map[string]string{"requests": requests})
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "missing events file") {
+		t.Errorf("uploading without events answered %s %q, want 400 about the missing events file", response.Status, body)
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/server/datasets"
+	"github.com/agavris/june-academy-go/src/server/jobs"
+	"github.com/gorilla/mux"
+	"golang.org/x/exp/slog"
+)
+
+// JobsHandler serves the asynchronous scheduling API under /jobs
+type JobsHandler struct {
+	Jobs      *jobs.Manager
+	Scheduler *scheduler.Scheduler
+	Datasets  *datasets.Store
+}
+
+func NewJobsHandler(manager *jobs.Manager, base *scheduler.Scheduler, store *datasets.Store) *JobsHandler {
+	return &JobsHandler{
+		Jobs:      manager,
+		Scheduler: base,
+		Datasets:  store,
+	}
+}
+
+// Create queues a scheduling run and answers with its status, taking the same parameters as /schedule
+func (h *JobsHandler) Create(writer http.ResponseWriter, request *http.Request) {
+	options, err := parseRunOptions(request)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		slog.Error("failed to parse run options", "err", err)
+		return
+	}
+
+	base, err := datasetScheduler(request, h.Scheduler, h.Datasets)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusNotFound)
+		slog.Error("failed to find dataset", "err", err)
+		return
+	}
+
+	// Every job gets its own scheduler, so concurrent jobs never share students or sections
+	s := base.Clone()
+	options.apply(s)
+	job, err := h.Jobs.Submit(s, options.iterations)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, jobs.ErrQueueFull) || errors.Is(err, jobs.ErrClosed) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(writer, err.Error(), status)
+		slog.Error("failed to submit job", "err", err)
+		return
+	}
+
+	writer.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(writer, http.StatusAccepted, job.Status())
+}
+
+// Status reports the state, progress and timing of a job
+func (h *JobsHandler) Status(writer http.ResponseWriter, request *http.Request) {
+	job, ok := h.job(writer, request)
+	if !ok {
+		return
+	}
+	writeJSON(writer, http.StatusOK, job.Status())
+}
+
+// Result returns the schedule of a finished job, or a conflict with its status while it has none
+func (h *JobsHandler) Result(writer http.ResponseWriter, request *http.Request) {
+	job, ok := h.job(writer, request)
+	if !ok {
+		return
+	}
+	result, state := job.Result()
+	if state != jobs.Succeeded {
+		writeJSON(writer, http.StatusConflict, job.Status())
+		return
+	}
+	writeJSON(writer, http.StatusOK, result)
+}
+
+// Cancel stops a queued or running job
+func (h *JobsHandler) Cancel(writer http.ResponseWriter, request *http.Request) {
+	job, ok := h.job(writer, request)
+	if !ok {
+		return
+	}
+	job.Cancel()
+	writeJSON(writer, http.StatusAccepted, job.Status())
+}
+
+func (h *JobsHandler) job(writer http.ResponseWriter, request *http.Request) (*jobs.Job, bool) {
+	job, ok := h.Jobs.Get(mux.Vars(request)["id"])
+	if !ok {
+		http.Error(writer, "Job not found", http.StatusNotFound)
+	}
+	return job, ok
+}
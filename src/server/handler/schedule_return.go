@@ -2,44 +2,156 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/imp"
+	"github.com/agavris/june-academy-go/src/server/datasets"
 	"golang.org/x/exp/slog"
 )
 
+// ScheduleHandler runs a schedule for every request to /schedule. Scheduler holds the input data loaded
+// from the working directory and Datasets the uploaded data. Both are only cloned, so concurrent requests
+// each run on their own state.
 type ScheduleHandler struct {
 	Scheduler *scheduler.Scheduler
+	Datasets  *datasets.Store
 }
 
-func NewScheduleHandler() *ScheduleHandler {
+func NewScheduleHandler(base *scheduler.Scheduler, store *datasets.Store) *ScheduleHandler {
 	return &ScheduleHandler{
-		Scheduler: scheduler.NewScheduler(),
+		Scheduler: base,
+		Datasets:  store,
 	}
 }
 
 func (h *ScheduleHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	iterations, err := strconv.Atoi(request.URL.Query().Get("iterations"))
+	options, err := parseRunOptions(request)
 	if err != nil {
-		http.Error(writer, "Invalid iterations parameter", http.StatusBadRequest)
-		slog.Error("failed to parse iterations", err)
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		slog.Error("failed to parse run options", "err", err)
 		return
 	}
 
-	resultSchedule := h.Scheduler.Run(iterations)
+	base, err := datasetScheduler(request, h.Scheduler, h.Datasets)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusNotFound)
+		slog.Error("failed to find dataset", "err", err)
+		return
+	}
+
+	s := base.Clone()
+	options.apply(s)
+	// Following the progress keeps the run from drawing a progress bar on the server's output
+	s.Progress = &scheduler.Progress{}
+	resultSchedule, err := s.RunContext(request.Context(), options.iterations)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		slog.Error("failed to run scheduler", "err", err)
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, resultSchedule)
+}
+
+// runOptions are the request parameters shared by the endpoints that start a scheduling run
+type runOptions struct {
+	iterations  int
+	strategy    scheduler.Strategy
+	weights     *imp.RankWeights
+	seed        int64
+	cancel      bool
+	groupWeight float64
+}
+
+func parseRunOptions(request *http.Request) (*runOptions, error) {
+	iterations, err := strconv.Atoi(request.FormValue("iterations"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid iterations parameter: %w", err)
+	}
+
+	strategy, err := scheduler.LookupStrategy(request.FormValue("strategy"))
+	if err != nil {
+		return nil, err
+	}
+
+	weights, err := parseWeights(request)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := time.Now().UnixNano()
+	if value := request.FormValue("seed"); value != "" {
+		seed, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed parameter: %w", err)
+		}
+	}
+
+	cancel := false
+	if value := request.FormValue("cancel"); value != "" {
+		cancel, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cancel parameter: %w", err)
+		}
+	}
+
+	groupWeight := scheduler.DefaultGroupWeight
+	if value := request.FormValue("groupWeight"); value != "" {
+		groupWeight, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid groupWeight parameter: %w", err)
+		}
+	}
+
+	return &runOptions{
+		iterations:  iterations,
+		strategy:    strategy,
+		weights:     weights,
+		seed:        seed,
+		cancel:      cancel,
+		groupWeight: groupWeight,
+	}, nil
+}
+
+func (o *runOptions) apply(s *scheduler.Scheduler) {
+	s.Strategy = o.strategy
+	s.Weights = o.weights
+	s.Seed = o.seed
+	s.CancelUnderEnrolled = o.cancel
+	s.GroupWeight = o.groupWeight
+}
+
+// parseWeights reads the optional weights and unrequested parameters, falling back to the default weights
+func parseWeights(request *http.Request) (*imp.RankWeights, error) {
+	weights := imp.DefaultRankWeights
+	if unrequested := request.FormValue("unrequested"); unrequested != "" {
+		value, err := strconv.ParseFloat(unrequested, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unrequested parameter: %w", err)
+		}
+		weights.Unrequested = value
+	}
+	if ranks := request.FormValue("weights"); ranks != "" {
+		return imp.ParseRankWeights(ranks, weights.Unrequested)
+	}
+	return &weights, nil
+}
 
-	response, err := json.Marshal(resultSchedule)
+func writeJSON(writer http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
 	if err != nil {
-		http.Error(writer, "Failed to marshal schedule", http.StatusInternalServerError)
-		slog.Error("failed to marshal schedule", err)
+		http.Error(writer, "Failed to marshal response", http.StatusInternalServerError)
+		slog.Error("failed to marshal response", "err", err)
 		return
 	}
 
 	writer.Header().Set("Content-Type", "application/json")
-	writer.WriteHeader(http.StatusOK)
-	_, writeErr := writer.Write(response)
-	if writeErr != nil {
-		slog.Error("failed to write response", err)
+	writer.WriteHeader(status)
+	if _, err := writer.Write(response); err != nil {
+		slog.Error("failed to write response", "err", err)
 	}
 }
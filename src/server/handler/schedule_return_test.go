@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/imp"
+	"github.com/agavris/june-academy-go/src/server/datasets"
+)
+
+// Both students want X first and Y second in the morning, and each of those has one seat, so one of them
+// always gets their second choice and the schedule scores the weight of the second rank
+const (
+	requests = "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+		"AM Course - 1st Choice. (Drop down option),AM Course - 2nd Choice. (Drop down option)," +
+		"PM Course - 1st Choice. (Drop down option)\n" +
+		"a@x.org,A,A,Junior,X,Y,Z\n" +
+		"b@x.org,B,B,Junior,X,Y,Z\n"
+	events = "Name,Max Students,Time Slot\nX,1,AM\nY,1,AM\nZ,2,PM\n"
+)
+
+// inTempDir runs the test in an empty directory, where the runs write their results
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestParallelSchedulesAnswerWithTheirOwnRun(t *testing.T) {
+	inTempDir(t)
+	loader, err := data.Load([]byte(requests), []byte(events), data.Options{})
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	base, err := scheduler.NewSchedulerWithData(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base.Workers = 1
+	// A schedule left on the shared scheduler must never be answered in place of a request's own run
+	base.BestSchedule = &scheduler.Schedule{Score: -1, Weights: &imp.DefaultRankWeights}
+	server := httptest.NewServer(NewScheduleHandler(base, datasets.NewStore(0, 0)))
+	defer server.Close()
+
+	const runs = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, runs)
+	for i := 0; i < runs; i++ {
+		// Every other run keeps the default weights, under which the stale schedule would compare
+		second := imp.DefaultRankWeights.Ranks[1]
+		url := fmt.Sprintf("%s/schedule?iterations=3&strategy=greedy&seed=%d", server.URL, i)
+		if i%2 == 1 {
+			second = float64(i + 1)
+			url += fmt.Sprintf("&weights=0,%g", second)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := http.Get(url)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer response.Body.Close()
+			if response.StatusCode != http.StatusOK {
+				errs <- fmt.Errorf("%s: status %s", url, response.Status)
+				return
+			}
+			var schedule scheduler.Schedule
+			if err := json.NewDecoder(response.Body).Decode(&schedule); err != nil {
+				errs <- fmt.Errorf("%s: %w", url, err)
+				return
+			}
+			if schedule.Score != second || schedule.Weights == nil || schedule.Weights.Ranks[1] != second || len(schedule.Students) != 2 {
+				errs <- fmt.Errorf("%s: answered a score of %g under %v for %d students, want %g for both", url, schedule.Score, schedule.Weights, len(schedule.Students), second)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,299 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+)
+
+type State string
+
+const (
+	Queued    State = "queued"
+	Running   State = "running"
+	Succeeded State = "succeeded"
+	Failed    State = "failed"
+	Cancelled State = "cancelled"
+)
+
+var (
+	ErrQueueFull = errors.New("the job queue is full, try again later")
+	ErrClosed    = errors.New("the server is shutting down")
+)
+
+// Job is a scheduling run started through the API
+type Job struct {
+	ID         string
+	Iterations int
+
+	scheduler *scheduler.Scheduler
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	mu         sync.Mutex
+	state      State
+	err        error
+	result     *scheduler.Schedule
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// Status is the JSON representation of a job
+type Status struct {
+	ID             string     `json:"id"`
+	State          State      `json:"state"`
+	Iterations     int        `json:"iterations"`
+	IterationsDone int        `json:"iterationsDone"`
+	BestScore      *float64   `json:"bestScore"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	FinishedAt     *time.Time `json:"finishedAt,omitempty"`
+	ElapsedSeconds float64    `json:"elapsedSeconds"`
+	Error          string     `json:"error,omitempty"`
+}
+
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := Status{
+		ID:         j.ID,
+		State:      j.state,
+		Iterations: j.Iterations,
+		CreatedAt:  j.createdAt,
+	}
+	status.IterationsDone, _ = j.scheduler.Progress.Iterations()
+	if best, ok := j.scheduler.Progress.BestScore(); ok {
+		status.BestScore = &best
+	}
+	if !j.startedAt.IsZero() {
+		startedAt := j.startedAt
+		status.StartedAt = &startedAt
+		if j.finishedAt.IsZero() {
+			status.ElapsedSeconds = time.Since(j.startedAt).Seconds()
+		} else {
+			status.ElapsedSeconds = j.finishedAt.Sub(j.startedAt).Seconds()
+		}
+	}
+	if !j.finishedAt.IsZero() {
+		finishedAt := j.finishedAt
+		status.FinishedAt = &finishedAt
+	}
+	if j.err != nil {
+		status.Error = j.err.Error()
+	}
+	return status
+}
+
+// Result returns the schedule of a job that succeeded, or nil and the job's state otherwise
+func (j *Job) Result() (*scheduler.Schedule, State) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result, j.state
+}
+
+// Cancel stops the job if it is still queued or running
+func (j *Job) Cancel() {
+	j.mu.Lock()
+	if j.state == Queued {
+		j.state = Cancelled
+		j.finishedAt = time.Now()
+	}
+	j.mu.Unlock()
+	j.cancel()
+}
+
+// finished returns when the job finished, or the zero time while it is queued or running
+func (j *Job) finished() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.finishedAt
+}
+
+func (j *Job) run() {
+	j.mu.Lock()
+	if j.state != Queued {
+		j.mu.Unlock()
+		return
+	}
+	j.state = Running
+	j.startedAt = time.Now()
+	j.mu.Unlock()
+
+	schedule, err := j.scheduler.RunContext(j.ctx, j.Iterations)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.finishedAt = time.Now()
+	switch {
+	case j.ctx.Err() != nil:
+		j.state = Cancelled
+	case err != nil:
+		j.state = Failed
+		j.err = err
+	default:
+		j.state = Succeeded
+		j.result = schedule
+	}
+	j.cancel()
+}
+
+// Manager runs the submitted jobs on a fixed number of workers
+type Manager struct {
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	queue  chan *Job
+	closed bool
+	wg     sync.WaitGroup
+	// Finished jobs are forgotten retention after they finish, and the oldest ones once more than
+	// maxFinished have finished. Zero keeps them for as long, or as many, as there are.
+	retention   time.Duration
+	maxFinished int
+	now         func() time.Time
+}
+
+// NewManager starts the given number of workers, accepting up to queueSize jobs waiting for one.
+// Finished jobs and their schedules are kept for retention and at most maxFinished of them, zero
+// leaving either unbounded.
+func NewManager(workers, queueSize int, retention time.Duration, maxFinished int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	m := &Manager{
+		jobs:        make(map[string]*Job),
+		queue:       make(chan *Job, queueSize),
+		retention:   retention,
+		maxFinished: maxFinished,
+		now:         time.Now,
+	}
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for job := range m.queue {
+				job.run()
+			}
+		}()
+	}
+	return m
+}
+
+// Submit queues a run of the scheduler. The scheduler must not be used by anything else.
+func (m *Manager) Submit(s *scheduler.Scheduler, iterations int) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Progress = &scheduler.Progress{}
+	job := &Job{
+		ID:         id,
+		Iterations: iterations,
+		scheduler:  s,
+		ctx:        ctx,
+		cancel:     cancel,
+		state:      Queued,
+		createdAt:  time.Now(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evict()
+	if m.closed {
+		cancel()
+		return nil, ErrClosed
+	}
+	select {
+	case m.queue <- job:
+	default:
+		cancel()
+		return nil, ErrQueueFull
+	}
+	m.jobs[id] = job
+	return job, nil
+}
+
+// Get returns the job with the given ID, unless it doesn't exist or finished long enough ago to be forgotten
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evict()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// evict forgets the finished jobs past the retention, then the oldest finished ones over the cap.
+// The caller holds m.mu.
+func (m *Manager) evict() {
+	if m.retention <= 0 && m.maxFinished <= 0 {
+		return
+	}
+	type finishedJob struct {
+		id string
+		at time.Time
+	}
+	var finished []finishedJob
+	now := m.now()
+	for id, job := range m.jobs {
+		at := job.finished()
+		if at.IsZero() {
+			continue
+		}
+		if m.retention > 0 && now.Sub(at) > m.retention {
+			delete(m.jobs, id)
+			continue
+		}
+		finished = append(finished, finishedJob{id, at})
+	}
+	if m.maxFinished <= 0 || len(finished) <= m.maxFinished {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].at.Before(finished[j].at)
+	})
+	for _, job := range finished[:len(finished)-m.maxFinished] {
+		delete(m.jobs, job.id)
+	}
+}
+
+// Shutdown stops accepting jobs, cancels the queued ones and waits for the running ones to finish
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+		for _, job := range m.jobs {
+			if _, state := job.Result(); state == Queued {
+				job.Cancel()
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+)
+
+const (
+	requests = "Email Address,Students First Name,Students Last Name,Grade in school this year," +
+		"AM Course - 1st Choice. (Drop down option),PM Course - 1st Choice. (Drop down option)\n" +
+		"a@x.org,A,A,Junior,X,Y\n"
+	events = "Name,Max Students,Time Slot\nX,1,AM\nY,1,PM\n"
+)
+
+// inTempDir runs the test in an empty directory, where the jobs write their results
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func newScheduler(t *testing.T) *scheduler.Scheduler {
+	t.Helper()
+	loader, err := data.Load([]byte(requests), []byte(events), data.Options{})
+	if err != nil {
+		t.Fatalf("loading the data: %v", err)
+	}
+	s, err := scheduler.NewSchedulerWithData(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Workers = 1
+	return s
+}
+
+// finish submits a job and waits for it to succeed
+func finish(t *testing.T, m *Manager) *Job {
+	t.Helper()
+	job, err := m.Submit(newScheduler(t), 1)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, state := job.Result()
+		if state == Succeeded {
+			return job
+		}
+		if state != Queued && state != Running {
+			t.Fatalf("job %s: %s", state, job.Status().Error)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManagerForgetsFinishedJobsAfterTheRetention(t *testing.T) {
+	inTempDir(t)
+	m := NewManager(1, 4, time.Hour, 0)
+	defer m.Shutdown(context.Background())
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	job := finish(t, m)
+	if _, ok := m.Get(job.ID); !ok {
+		t.Fatal("a job that just finished was forgotten")
+	}
+	now = now.Add(2 * time.Hour)
+	if _, ok := m.Get(job.ID); ok {
+		t.Error("a job that finished past the retention is still kept")
+	}
+}
+
+func TestManagerKeepsTheNewestFinishedJobs(t *testing.T) {
+	inTempDir(t)
+	m := NewManager(1, 4, 0, 2)
+	defer m.Shutdown(context.Background())
+
+	var finished []*Job
+	for i := 0; i < 3; i++ {
+		finished = append(finished, finish(t, m))
+	}
+	if _, ok := m.Get(finished[0].ID); ok {
+		t.Error("the oldest finished job is still kept over the cap")
+	}
+	for _, job := range finished[1:] {
+		if _, ok := m.Get(job.ID); !ok {
+			t.Errorf("job %s was forgotten, want the 2 newest kept", job.ID)
+		}
+	}
+}
@@ -1,16 +1,48 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"github.com/agavris/june-academy-go/src/algorithm/scheduler"
+	"github.com/agavris/june-academy-go/src/algorithm/utils/data"
+	"github.com/agavris/june-academy-go/src/server/datasets"
 	"github.com/agavris/june-academy-go/src/server/handler"
+	"github.com/agavris/june-academy-go/src/server/jobs"
 	"github.com/gorilla/mux"
+	"net"
 	"net/http"
+	"time"
 )
 
-func Serve(port string) error {
+// Config holds the settings of the HTTP server
+type Config struct {
+	Addr            string
+	Port            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+	// JobWorkers bounds the number of jobs running at once, JobQueueSize the number waiting for a worker
+	JobWorkers   int
+	JobQueueSize int
+	// JobRetention is how long finished jobs and their schedules are kept, MaxFinishedJobs how many of them.
+	// Zero leaves either unbounded.
+	JobRetention    time.Duration
+	MaxFinishedJobs int
+	// DatasetRetention is how long an uploaded dataset is kept after it was last used, MaxDatasets how
+	// many are kept. Zero leaves either unbounded.
+	DatasetRetention time.Duration
+	MaxDatasets      int
+	// Data changes how the input files in the working directory are read
+	Data data.Options
+}
+
+// Serve listens until the context is cancelled, then stops accepting connections and waits
+// for in-flight requests, the scheduling runs they started and running jobs to finish before returning.
+// A zero ShutdownTimeout waits for as long as they take.
+func Serve(ctx context.Context, config Config) error {
 	// if we don't have a port we can't serve
-	if port == "" {
+	if config.Port == "" {
 		return errors.New("must specify a port")
 	}
 
@@ -18,11 +50,66 @@ func Serve(port string) error {
 	// http requests / paths we want to enable
 	router := mux.NewRouter()
 
-	router.Handle("/schedule", handler.NewScheduleHandler())
+	// The input data is loaded once and every request clones it
+	loader := data.NewDataLoaderWithOptions(config.Data)
+	if err := loader.Check(); err != nil {
+		return err
+	}
+	base, err := scheduler.NewSchedulerWithData(loader)
+	if err != nil {
+		return err
+	}
+	store := datasets.NewStore(config.DatasetRetention, config.MaxDatasets)
+	router.Handle("/schedule", handler.NewScheduleHandler(base, store))
+
+	datasetsHandler := handler.NewDatasetsHandler(store)
+	router.HandleFunc("/datasets", datasetsHandler.Create).Methods(http.MethodPost)
+	router.HandleFunc("/datasets/{id}", datasetsHandler.Get).Methods(http.MethodGet)
+	router.HandleFunc("/datasets/{id}", datasetsHandler.Delete).Methods(http.MethodDelete)
+
+	manager := jobs.NewManager(config.JobWorkers, config.JobQueueSize, config.JobRetention, config.MaxFinishedJobs)
+	jobsHandler := handler.NewJobsHandler(manager, base, store)
+	router.HandleFunc("/jobs", jobsHandler.Create).Methods(http.MethodPost)
+	router.HandleFunc("/jobs/{id}", jobsHandler.Status).Methods(http.MethodGet)
+	router.HandleFunc("/jobs/{id}", jobsHandler.Cancel).Methods(http.MethodDelete)
+	router.HandleFunc("/jobs/{id}/result", jobsHandler.Result).Methods(http.MethodGet)
 
 	// set up the address to listen on
-	addr := fmt.Sprintf(":%s", port)
+	srv := &http.Server{
+		Addr:         net.JoinHostPort(config.Addr, config.Port),
+		Handler:      router,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+	}
 
-	fmt.Println("Listening on", addr)
-	return http.ListenAndServe(addr, router)
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Println("Listening on", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down, waiting for in-flight scheduling runs to finish")
+	shutdownCtx := context.Background()
+	if config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, config.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	// Queued jobs are cancelled, running ones are left to finish
+	if err := manager.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }